@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/agent"
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/control"
+)
+
+// newControlCommands builds the client subcommands that talk to a running agent.
+func newControlCommands(cfgFile *string) []*cobra.Command {
+	var pauseFor time.Duration
+
+	pauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause tracking in the running agent",
+		Long: `Pause tracking in the running agent. In-progress sessions are published and ended.
+Without --for, tracking stays paused until "awagent resume".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := control.Request{Command: control.CommandPause}
+			if pauseFor > 0 {
+				req.Duration = pauseFor.String()
+			}
+			return sendControl(*cfgFile, req)
+		},
+	}
+	pauseCmd.Flags().DurationVar(&pauseFor, "for", 0, "resume tracking automatically after this duration (e.g. 30m)")
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume tracking in the running agent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendControl(*cfgFile, control.Request{Command: control.CommandResume})
+		},
+	}
+
+	flushCmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Publish and end all in-progress sessions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendControl(*cfgFile, control.Request{Command: control.CommandFlush})
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show live status of the running agent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendControl(*cfgFile, control.Request{Command: control.CommandStatus})
+		},
+	}
+
+	reposCmd := &cobra.Command{
+		Use:   "repos",
+		Short: "Manage the running agent's repository index",
+	}
+	reposCmd.AddCommand(&cobra.Command{
+		Use:   "reindex",
+		Short: "Discard the repository index and rescan every root",
+		Long: `Discard the persisted repository index and walk every configured root again.
+Periodic rescans only revisit directories whose modification time changed; use this
+after changing files in a way mtimes don't reflect (e.g. restoring from backup).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendControl(*cfgFile, control.Request{Command: control.CommandReindex})
+		},
+	})
+
+	return []*cobra.Command{pauseCmd, resumeCmd, flushCmd, statusCmd, reposCmd}
+}
+
+func sendControl(cfgFile string, req control.Request) error {
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	resp, err := control.Send(cfg.Control.SocketPath, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.Status != nil {
+		printStatus(*resp.Status)
+	}
+	return nil
+}
+
+func printStatus(status agent.Status) {
+	switch {
+	case status.Paused && !status.PausedUntil.IsZero():
+		fmt.Printf("Tracking:     paused until %s\n", status.PausedUntil.Local().Format(time.Kitchen))
+	case status.Paused:
+		fmt.Println("Tracking:     paused")
+	default:
+		fmt.Println("Tracking:     active")
+	}
+	fmt.Printf("Repositories: %d\n", status.Repositories)
+	if scan := status.LastScan; !scan.At.IsZero() {
+		truncated := ""
+		if scan.Truncated {
+			truncated = ", stopped at budget"
+		}
+		fmt.Printf("Last scan:    %s ago, %d dirs read, %d cached in %s, %d ignored, %d errors%s\n",
+			time.Since(scan.At).Round(time.Second), scan.Directories, scan.Cached, scan.Duration.Round(time.Millisecond), scan.Ignored, scan.Errors, truncated)
+	}
+	fmt.Printf("Pending:      %d publish(es)\n", status.PendingPublishes)
+
+	if len(status.Sessions) == 0 {
+		fmt.Println("Sessions:     none")
+		return
+	}
+
+	fmt.Println("Sessions:")
+	for _, sess := range status.Sessions {
+		branch := sess.Branch
+		if sess.Operation != "" {
+			branch += ", " + sess.Operation
+		}
+		fmt.Printf("  %s [%s] age=%s events=%d commits=%d app=%s\n",
+			sess.Repo, branch, sess.Age.Round(time.Second), sess.Events, sess.Commits, sess.App)
+		fmt.Printf("    %s (last activity %s ago)\n",
+			sess.Path, time.Since(sess.LastActivity).Round(time.Second))
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/control"
+	"github.com/liamdn8/auto-worklog-agent/internal/hooks"
+)
+
+// newHooksCommand builds the subcommands that manage git hooks notifying the agent.
+func newHooksCommand(cfgFile *string) *cobra.Command {
+	var global bool
+	var globalDir string
+	var force bool
+
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage git hooks that notify the running agent",
+	}
+	hooksCmd.PersistentFlags().BoolVar(&global, "global", false, "use a global core.hooksPath for every repository instead of per-repository hooks")
+	hooksCmd.PersistentFlags().StringVar(&globalDir, "dir", "", "hooks directory for --global (default: <user config dir>/awagent/hooks)")
+
+	installCmd := &cobra.Command{
+		Use:   "install [repo...]",
+		Short: "Install post-commit, post-checkout, post-merge and post-rewrite hooks",
+		Long: `Install git hooks that tell the running agent about commits, checkouts, merges
+and rewrites as they happen, so work done from a terminal is recorded precisely.
+
+Without --global the hooks go into each given repository (default: the current one),
+honouring its core.hooksPath; existing hooks are renamed to <hook>.awagent-orig and
+still run. With --global they go into one directory set as the global core.hooksPath,
+and chain to each repository's own .git/hooks or to the previous global hooks path.
+Repositories with their own core.hooksPath (e.g. husky) need a per-repository install,
+which refuses a hooks directory inside the working tree unless --force is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notify, err := hookNotifier(*cfgFile)
+			if err != nil {
+				return err
+			}
+			if global {
+				dir, err := globalHooksDir(globalDir)
+				if err != nil {
+					return err
+				}
+				if err := hooks.InstallGlobal(dir, notify); err != nil {
+					return err
+				}
+				fmt.Printf("Installed global hooks in %s (core.hooksPath)\n", dir)
+				return nil
+			}
+			for _, repo := range reposOrCwd(args) {
+				dir, err := hooks.InstallRepo(repo, notify, force)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Installed hooks in %s\n", dir)
+			}
+			return nil
+		},
+	}
+
+	installCmd.Flags().BoolVar(&force, "force", false, "install into a hooks directory inside the working tree (e.g. .husky)")
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall [repo...]",
+		Short: "Remove awagent hooks and restore the hooks they replaced",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if global {
+				dir, err := globalHooksDir(globalDir)
+				if err != nil {
+					return err
+				}
+				if err := hooks.UninstallGlobal(dir); err != nil {
+					return err
+				}
+				fmt.Printf("Removed global hooks from %s\n", dir)
+				return nil
+			}
+			for _, repo := range reposOrCwd(args) {
+				dir, err := hooks.UninstallRepo(repo)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Removed hooks from %s\n", dir)
+			}
+			return nil
+		},
+	}
+
+	notifyCmd := &cobra.Command{
+		Use:    "notify <hook>",
+		Short:  "Tell the running agent that a git hook ran (called by the installed hooks)",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadConfig(*cfgFile)
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			_, err = control.Send(cfg.Control.SocketPath, control.Request{Command: control.CommandGitHook, Hook: args[0], Path: cwd})
+			return err
+		},
+	}
+
+	hooksCmd.AddCommand(installCmd, uninstallCmd, notifyCmd)
+	return hooksCmd
+}
+
+// hookNotifier is the command line hooks run: this executable, with the
+// config file in use.
+func hookNotifier(cfgFile string) (hooks.Notifier, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate awagent executable: %w", err)
+	}
+	notify := hooks.Notifier{exe}
+	if cfgFile != "" {
+		abs, err := filepath.Abs(cfgFile)
+		if err != nil {
+			return nil, fmt.Errorf("resolve config path: %w", err)
+		}
+		notify = append(notify, "--config", abs)
+	}
+	return notify, nil
+}
+
+func globalHooksDir(dir string) (string, error) {
+	if dir != "" {
+		return filepath.Abs(dir)
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config directory: %w", err)
+	}
+	return filepath.Join(base, "awagent", "hooks"), nil
+}
+
+func reposOrCwd(args []string) []string {
+	if len(args) == 0 {
+		return []string{"."}
+	}
+	return args
+}
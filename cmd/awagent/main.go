@@ -12,6 +12,7 @@ import (
 	"github.com/liamdn8/auto-worklog-agent/internal/activitywatch"
 	"github.com/liamdn8/auto-worklog-agent/internal/agent"
 	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/control"
 )
 
 func main() {
@@ -42,6 +43,10 @@ Use --test mode to simulate activity for testing without aw-watcher-window.`,
 				cfg.ActivityWatch.Machine = overrideMachine
 			}
 
+			if verbose {
+				cfg.Verbose = true
+			}
+
 			ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 			defer cancel()
 
@@ -56,6 +61,13 @@ Use --test mode to simulate activity for testing without aw-watcher-window.`,
 			log.Printf("Configuration: server=%s machine=%s", cfg.ActivityWatch.BaseURL, cfg.ActivityWatch.Machine)
 			log.Printf("Git scan roots: %v (maxDepth=%d, rescan=%dm)", cfg.Git.Roots, cfg.Git.MaxDepth, cfg.Git.RescanIntervalMin)
 
+			controlServer := control.NewServer(cfg.Control.SocketPath, sessionTracker)
+			go func() {
+				if err := controlServer.Serve(ctx); err != nil {
+					log.Printf("Control socket disabled: %v", err)
+				}
+			}()
+
 			if testMode {
 				log.Println("TEST MODE: Simulating IDE activity without aw-watcher-window")
 				return sessionTracker.RunTest(ctx)
@@ -71,6 +83,9 @@ Use --test mode to simulate activity for testing without aw-watcher-window.`,
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose logging")
 	rootCmd.PersistentFlags().BoolVar(&testMode, "test", false, "run in test mode (simulate activity without aw-watcher-window)")
 
+	rootCmd.AddCommand(newControlCommands(&cfgFile)...)
+	rootCmd.AddCommand(newHooksCommand(&cfgFile))
+
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatalf("command failed: %v", err)
 	}
@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/watcher"
+)
+
+const (
+	defaultCategory = "coding"
+	reviewCategory  = "review"
+)
+
+// browserClass matches common browser window classes and process names.
+const browserClass = `(?i)^(firefox|firefox-esr|navigator|librewolf|google-chrome|chrome|chromium|chromium-browser|brave|brave-browser|microsoft-edge|msedge|vivaldi|vivaldi-stable|opera|safari|zen)$`
+
+// defaultAppRules are the built-in IDE definitions used unless disabled with
+// apps.includeDefaults=false. Configured rules are evaluated first.
+var defaultAppRules = []config.AppRule{
+	{
+		Name:  "vscode",
+		Class: `(?i)^(code|code - oss|code-oss|vscodium|visual studio code)$`,
+		// Distribution builds run VS Code on the system electron
+		Cmdline: `(?i)^\S*electron\S*\s.*/(code|code-oss|vscodium)/out/(main|cli)\.js\b`,
+	},
+	{
+		Name:  "jetbrains",
+		Class: `(?i)^jetbrains-|^(intellij idea|idea|goland|clion|webstorm|phpstorm|pycharm|rider|rubymine|dataspell|android studio)(?:64)?(?:\.exe)?\b`,
+		// IDEs started through a plain java launcher
+		Cmdline: `^\S*java\S*\s.*\bcom\.intellij\.idea\.Main\b`,
+	},
+	{Name: "vim", Class: `(?i)^(g?vim|n?vim|neovim|neovide|nvim-qt)$`},
+	{Name: "vim", Title: `(?i)\s-\s(g?n?vim|neovim)\d*$`},
+	{Name: "emacs", Class: `(?i)^emacs`},
+	{Name: "sublime", Class: `(?i)^sublime`},
+	{
+		Name:       "terminal",
+		Class:      `(?i)^(gnome-terminal|gnome-terminal-server|org\.gnome\.console|kgx|konsole|alacritty|kitty|xterm|uxterm|urxvt|rxvt|terminator|tilix|wezterm|org\.wezfurlong\.wezterm|foot|footclient|xfce4-terminal|st|st-256color|qterminal|lxterminal|mate-terminal|terminology|ghostty|com\.mitchellh\.ghostty)$`,
+		Category:   "terminal",
+		ProcessCwd: true,
+	},
+	{
+		Name:     "github-review",
+		Class:    browserClass,
+		Title:    `· Pull Request #(?P<pr>\d+) · (?P<remote>[\w.-]+/[\w.-]+)`,
+		Category: reviewCategory,
+		Forge:    "github.com",
+	},
+	{
+		Name:     "gitlab-review",
+		Class:    browserClass,
+		Title:    `\(!(?P<pr>\d+)\) · Merge [Rr]equests? · (?P<remote>[^·]+?) · GitLab`,
+		Category: reviewCategory,
+		Forge:    "gitlab.com", // replaced by apps.gitlabHost
+	},
+	{
+		Name:     "bitbucket-review",
+		Class:    browserClass,
+		Title:    `(?P<remote>[\w.-]+\s*/\s*[\w.-]+)\s*/\s*Pull [Rr]equest #(?P<pr>\d+).*Bitbucket`,
+		Category: reviewCategory,
+		Forge:    "bitbucket.org",
+	},
+}
+
+// appRule is a compiled config.AppRule.
+type appRule struct {
+	name       string
+	class      *regexp.Regexp
+	exe        *regexp.Regexp
+	cmdline    *regexp.Regexp
+	title      *regexp.Regexp
+	forge      string
+	category   string
+	processCwd bool
+}
+
+// appMatch is the result of matching a window against the app rules.
+type appMatch struct {
+	rule   appRule
+	parsed TitleInfo
+}
+
+func compileAppRules(cfg config.AppsConfig) ([]appRule, error) {
+	defs := append([]config.AppRule{}, cfg.Rules...)
+	if cfg.IncludeDefaults {
+		for _, def := range defaultAppRules {
+			if def.Name == "gitlab-review" && cfg.GitLabHost != "" {
+				def.Forge = cfg.GitLabHost
+			}
+			defs = append(defs, def)
+		}
+	}
+
+	rules := make([]appRule, 0, len(defs))
+	for i, def := range defs {
+		rule := appRule{name: def.Name, category: def.Category, forge: strings.ToLower(def.Forge), processCwd: def.ProcessCwd}
+		if rule.category == "" {
+			rule.category = defaultCategory
+		}
+
+		var err error
+		if rule.class, err = compileOptional(def.Class); err != nil {
+			return nil, fmt.Errorf("app rule %d (%s): class: %w", i, def.Name, err)
+		}
+		if rule.exe, err = compileOptional(def.Exe); err != nil {
+			return nil, fmt.Errorf("app rule %d (%s): exe: %w", i, def.Name, err)
+		}
+		if rule.cmdline, err = compileOptional(def.Cmdline); err != nil {
+			return nil, fmt.Errorf("app rule %d (%s): cmdline: %w", i, def.Name, err)
+		}
+		if rule.title, err = compileOptional(def.Title); err != nil {
+			return nil, fmt.Errorf("app rule %d (%s): title: %w", i, def.Name, err)
+		}
+		if rule.class == nil && rule.exe == nil && rule.cmdline == nil && rule.title == nil {
+			return nil, fmt.Errorf("app rule %d (%s): needs class, exe, cmdline or title", i, def.Name)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func compileOptional(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// matchApp returns the first rule matching the window. Class, exe and cmdline
+// are alternatives; a title regex, when present, must match as well.
+func matchApp(rules []appRule, window watcher.WindowInfo) (appMatch, bool) {
+	for _, rule := range rules {
+		if !rule.matchesProgram(window) {
+			continue
+		}
+
+		if rule.title == nil {
+			parsed, _ := parseTitle(window.App, window.Title)
+			return appMatch{rule: rule, parsed: parsed}, true
+		}
+
+		m := rule.title.FindStringSubmatch(window.Title)
+		if m == nil {
+			continue
+		}
+		parsed := titleFromGroups(rule.title, m)
+		if parsed.Workspace == "" && parsed.File == "" && parsed.WorkspacePath == "" && parsed.Remote == "" {
+			// No capture groups: let the built-in parsers have a go
+			parsed, _ = parseTitle(window.App, window.Title)
+		}
+		if parsed.Remote != "" {
+			parsed.Forge = rule.forge
+		}
+		return appMatch{rule: rule, parsed: parsed}, true
+	}
+	return appMatch{}, false
+}
+
+func (r appRule) matchesProgram(window watcher.WindowInfo) bool {
+	if r.class == nil && r.exe == nil && r.cmdline == nil {
+		return true
+	}
+	if r.class != nil && r.class.MatchString(window.App) {
+		return true
+	}
+	if r.exe != nil && window.Exe != "" && (r.exe.MatchString(window.Exe) || r.exe.MatchString(filepath.Base(window.Exe))) {
+		return true
+	}
+	return r.cmdline != nil && len(window.Cmdline) > 0 && r.cmdline.MatchString(strings.Join(window.Cmdline, " "))
+}
+
+func titleFromGroups(re *regexp.Regexp, m []string) TitleInfo {
+	info := TitleInfo{Parser: "config"}
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		value := strings.TrimSpace(m[i])
+		switch name {
+		case "workspace":
+			info.Workspace = value
+		case "workspacePath":
+			info.WorkspacePath = expandHome(value)
+		case "file":
+			info.File = expandHome(value)
+		case "dirty":
+			info.Dirty = value != ""
+		case "remote":
+			// GitLab renders nested groups as "group / sub / repo"
+			info.Remote = strings.ToLower(strings.Join(strings.Fields(value), ""))
+		case "pr":
+			info.Review = value
+		}
+	}
+	return info
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/watcher"
+)
+
+func TestMatchAppReviewTitles(t *testing.T) {
+	rules, err := compileAppRules(config.AppsConfig{IncludeDefaults: true, GitLabHost: "gitlab.com"})
+	if err != nil {
+		t.Fatalf("compileAppRules: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		window watcher.WindowInfo
+		rule   string
+		remote string
+		forge  string
+		review string
+		ok     bool
+	}{
+		{
+			name:   "github pull request",
+			window: watcher.WindowInfo{App: "firefox", Title: "Fix X by dev · Pull Request #12 · acme/api · GitHub — Mozilla Firefox"},
+			rule:   "github-review",
+			remote: "acme/api",
+			forge:  "github.com",
+			review: "12",
+			ok:     true,
+		},
+		{
+			name:   "gitlab merge request in nested group",
+			window: watcher.WindowInfo{App: "Google-chrome", Title: "Add cache (!34) · Merge requests · platform / backend / billing · GitLab - Google Chrome"},
+			rule:   "gitlab-review",
+			remote: "platform/backend/billing",
+			forge:  "gitlab.com",
+			review: "34",
+			ok:     true,
+		},
+		{
+			name:   "bitbucket pull request",
+			window: watcher.WindowInfo{App: "chromium", Title: "acme / shop / Pull Request #7: Checkout flow — Bitbucket - Chromium"},
+			rule:   "bitbucket-review",
+			remote: "acme/shop",
+			forge:  "bitbucket.org",
+			review: "7",
+			ok:     true,
+		},
+		{
+			name:   "ordinary browsing",
+			window: watcher.WindowInfo{App: "firefox", Title: "Go Documentation — Mozilla Firefox"},
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchApp(rules, tt.window)
+			if ok != tt.ok {
+				t.Fatalf("matchApp(%q) ok = %v, want %v", tt.window.Title, ok, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			if got.rule.name != tt.rule || got.rule.category != reviewCategory {
+				t.Errorf("rule = %s/%s, want %s/%s", got.rule.name, got.rule.category, tt.rule, reviewCategory)
+			}
+			if got.parsed.Remote != tt.remote || got.parsed.Forge != tt.forge || got.parsed.Review != tt.review {
+				t.Errorf("parsed remote=%q forge=%q review=%q, want remote=%q forge=%q review=%q",
+					got.parsed.Remote, got.parsed.Forge, got.parsed.Review, tt.remote, tt.forge, tt.review)
+			}
+		})
+	}
+}
+
+func TestMatchAppJetBrainsClasses(t *testing.T) {
+	rules, err := compileAppRules(config.AppsConfig{IncludeDefaults: true})
+	if err != nil {
+		t.Fatalf("compileAppRules: %v", err)
+	}
+
+	tests := []struct {
+		app string
+		ok  bool
+	}{
+		{app: "jetbrains-goland", ok: true},
+		{app: "IntelliJ IDEA", ok: true},
+		{app: "idea64", ok: true},
+		{app: "goland64.exe", ok: true},
+		{app: "pycharm64", ok: true},
+		{app: "rider.exe", ok: true},
+		{app: "ideapad-settings", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.app, func(t *testing.T) {
+			got, ok := matchApp(rules, watcher.WindowInfo{App: tt.app, Title: "api – main.go"})
+			if matched := ok && got.rule.name == "jetbrains"; matched != tt.ok {
+				t.Errorf("matchApp(%q) jetbrains = %v, want %v", tt.app, matched, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMatchAppCmdline(t *testing.T) {
+	rules, err := compileAppRules(config.AppsConfig{IncludeDefaults: true})
+	if err != nil {
+		t.Fatalf("compileAppRules: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		exe     string
+		cmdline []string
+		rule    string
+	}{
+		{name: "java launcher", exe: "/usr/lib/jvm/java-17/bin/java", cmdline: []string{"/usr/lib/jvm/java-17/bin/java", "-Xmx2g", "-classpath", "/opt/idea/lib/app.jar", "com.intellij.idea.Main"}, rule: "jetbrains"},
+		{name: "system electron", exe: "/usr/lib/electron32/electron", cmdline: []string{"/usr/lib/electron32/electron", "--no-sandbox", "/usr/lib/code/out/cli.js"}, rule: "vscode"},
+		{name: "other java app", exe: "/usr/bin/java", cmdline: []string{"/usr/bin/java", "-jar", "minecraft.jar"}},
+		{name: "other electron app", exe: "/usr/lib/electron/electron", cmdline: []string{"/usr/lib/electron/electron", "/usr/lib/slack/app.asar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchApp(rules, watcher.WindowInfo{App: "unknown", Title: "main.go - api", Exe: tt.exe, Cmdline: tt.cmdline})
+			if !ok {
+				got.rule.name = ""
+			}
+			if got.rule.name != tt.rule {
+				t.Errorf("matchApp rule = %q, want %q", got.rule.name, tt.rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+// Status is a point-in-time snapshot of the tracker reported over the control socket.
+type Status struct {
+	Paused           bool            `json:"paused"`
+	PausedUntil      time.Time       `json:"pausedUntil,omitempty"`
+	Repositories     int             `json:"repositories"`
+	LastScan         ScanStatus      `json:"lastScan"`
+	PendingPublishes int             `json:"pendingPublishes"`
+	Sessions         []SessionStatus `json:"sessions"`
+}
+
+// ScanStatus describes the most recent repository scan.
+type ScanStatus struct {
+	gitinfo.DiscoveryStats
+	At time.Time `json:"at"`
+}
+
+// SessionStatus describes a single in-progress session.
+type SessionStatus struct {
+	Repo         string        `json:"repo"`
+	Path         string        `json:"path"`
+	Branch       string        `json:"branch"`
+	Operation    string        `json:"operation,omitempty"`
+	App          string        `json:"app,omitempty"`
+	Start        time.Time     `json:"start"`
+	LastActivity time.Time     `json:"lastActivity"`
+	Age          time.Duration `json:"age"`
+	Events       int           `json:"events"`
+	Commits      int           `json:"commits"`
+}
+
+// controlRequest is a pause or flush from the control socket. Run applies it,
+// so no event is recorded or session published while it is in progress, and
+// closes done afterwards.
+type controlRequest struct {
+	pause    bool
+	duration time.Duration
+	done     chan struct{}
+}
+
+// Pause stops tracking and ends in-progress sessions. A zero duration pauses
+// until Resume is called; otherwise tracking resumes automatically afterwards.
+// It returns once Run has applied the pause or ctx is done.
+func (t *Tracker) Pause(ctx context.Context, d time.Duration) {
+	t.control(ctx, controlRequest{pause: true, duration: d})
+}
+
+// Flush publishes and ends all in-progress sessions immediately. It returns
+// once Run has flushed or ctx is done.
+func (t *Tracker) Flush(ctx context.Context) {
+	log.Printf("Flush requested via control socket")
+	t.control(ctx, controlRequest{})
+}
+
+// control hands a request to Run and waits for it to be applied.
+func (t *Tracker) control(ctx context.Context, req controlRequest) {
+	req.done = make(chan struct{})
+	select {
+	case t.controls <- req:
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-req.done:
+	case <-ctx.Done():
+	}
+}
+
+// applyControl carries out a control request on the Run goroutine.
+func (t *Tracker) applyControl(ctx context.Context, req controlRequest) {
+	defer close(req.done)
+	if req.pause {
+		t.pause(req.duration)
+	}
+	t.flushAll(ctx)
+}
+
+func (t *Tracker) pause(d time.Duration) {
+	t.pauseMu.Lock()
+	t.paused = true
+	t.pausedUntil = time.Time{}
+	if d > 0 {
+		t.pausedUntil = time.Now().Add(d)
+	}
+	t.pauseMu.Unlock()
+
+	if d > 0 {
+		log.Printf("Tracking paused for %s", d)
+	} else {
+		log.Printf("Tracking paused until resumed")
+	}
+}
+
+// Resume re-enables tracking after Pause.
+func (t *Tracker) Resume() {
+	t.pauseMu.Lock()
+	wasPaused := t.paused
+	t.paused = false
+	t.pausedUntil = time.Time{}
+	t.pauseMu.Unlock()
+
+	if wasPaused {
+		log.Printf("Tracking resumed")
+	}
+}
+
+// Status returns a snapshot of the tracker state.
+func (t *Tracker) Status() Status {
+	paused, until := t.pauseState()
+
+	t.repoMu.RLock()
+	repoCount := len(t.repos)
+	lastScan := ScanStatus{DiscoveryStats: t.lastScan, At: t.lastScanAt}
+	t.repoMu.RUnlock()
+
+	t.pendingMu.Lock()
+	pending := len(t.pending)
+	t.pendingMu.Unlock()
+
+	now := time.Now()
+	t.mu.Lock()
+	sessions := make([]SessionStatus, 0, len(t.sessions))
+	for _, sess := range t.sessions {
+		sessions = append(sessions, SessionStatus{
+			Repo:         sess.Repo.Name,
+			Path:         sess.Repo.Path,
+			Branch:       sess.Branch,
+			Operation:    sess.Operation,
+			App:          sess.App,
+			Start:        sess.Start,
+			LastActivity: sess.LastActivity,
+			Age:          now.Sub(sess.Start),
+			Events:       sess.Events,
+			Commits:      len(sess.Commits),
+		})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivity.After(sessions[j].LastActivity)
+	})
+
+	return Status{
+		Paused:           paused,
+		PausedUntil:      until,
+		Repositories:     repoCount,
+		LastScan:         lastScan,
+		PendingPublishes: pending,
+		Sessions:         sessions,
+	}
+}
+
+// pauseState reports whether tracking is paused, lifting expired timed pauses.
+func (t *Tracker) pauseState() (bool, time.Time) {
+	t.pauseMu.Lock()
+	defer t.pauseMu.Unlock()
+
+	if t.paused && !t.pausedUntil.IsZero() && time.Now().After(t.pausedUntil) {
+		t.paused = false
+		t.pausedUntil = time.Time{}
+		log.Printf("Timed pause elapsed, tracking resumed")
+	}
+	return t.paused, t.pausedUntil
+}
+
+func (t *Tracker) isPaused() bool {
+	paused, _ := t.pauseState()
+	return paused
+}
+
+func (t *Tracker) markPending(repoPath string, failed bool) {
+	t.pendingMu.Lock()
+	defer t.pendingMu.Unlock()
+	if failed {
+		t.pending[repoPath] = struct{}{}
+	} else {
+		delete(t.pending, repoPath)
+	}
+}
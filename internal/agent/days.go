@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"time"
+)
+
+// nextDayBoundary returns the first reporting-day boundary strictly after ts.
+// A day starts at dayStartHour in the configured location.
+func (t *Tracker) nextDayBoundary(ts time.Time) time.Time {
+	local := ts.In(t.location)
+	boundary := t.dayStart(local.Year(), local.Month(), local.Day())
+	if !local.Before(boundary) {
+		boundary = t.dayStart(local.Year(), local.Month(), local.Day()+1)
+	}
+	return boundary
+}
+
+// dayStart returns when the given reporting day starts. When a DST change
+// skips dayStartHour, the day starts at the change.
+func (t *Tracker) dayStart(year int, month time.Month, day int) time.Time {
+	start := time.Date(year, month, day, t.dayStartHour, 0, 0, 0, t.location)
+	if start.Hour() != t.dayStartHour {
+		// time.Date normalised a skipped wall time using the offset before the change
+		if _, end := start.ZoneBounds(); !end.IsZero() {
+			return end
+		}
+	}
+	return start
+}
+
+// crossesDay reports whether a reporting-day boundary lies between from and to.
+func (t *Tracker) crossesDay(from, to time.Time) bool {
+	return !to.Before(t.nextDayBoundary(from))
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordEventSplitsAtDayBoundary(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		dayStart int
+		start    time.Time
+		event    time.Time
+		boundary time.Time
+	}{
+		{
+			name:     "midnight",
+			location: time.UTC,
+			start:    time.Date(2026, 3, 9, 23, 50, 0, 0, time.UTC),
+			event:    time.Date(2026, 3, 10, 0, 10, 0, 0, time.UTC),
+			boundary: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day start hour",
+			location: time.UTC,
+			dayStart: 4,
+			start:    time.Date(2026, 3, 10, 3, 30, 0, 0, time.UTC),
+			event:    time.Date(2026, 3, 10, 4, 20, 0, 0, time.UTC),
+			boundary: time.Date(2026, 3, 10, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "midnight in local time",
+			location: newYork,
+			start:    time.Date(2024, 3, 9, 23, 40, 0, 0, newYork),
+			event:    time.Date(2024, 3, 10, 0, 20, 0, 0, newYork),
+			boundary: time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day start skipped by spring forward",
+			location: newYork,
+			dayStart: 2,
+			start:    time.Date(2024, 3, 10, 1, 30, 0, 0, newYork),
+			event:    time.Date(2024, 3, 10, 3, 30, 0, 0, newYork),
+			boundary: time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "25 hour day after fall back",
+			location: newYork,
+			start:    time.Date(2024, 11, 3, 0, 30, 0, 0, newYork),
+			event:    time.Date(2024, 11, 4, 0, 10, 0, 0, newYork),
+			boundary: time.Date(2024, 11, 4, 5, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, _ := newTestTracker(t)
+			tracker.location = tt.location
+			tracker.dayStartHour = tt.dayStart
+
+			if got := tracker.nextDayBoundary(tt.start); !got.Equal(tt.boundary) {
+				t.Fatalf("nextDayBoundary(%s) = %s, want %s", tt.start, got, tt.boundary)
+			}
+
+			tracker.recordEvent(testEvent(tt.start, "main"))
+			tracker.recordEvent(testEvent(tt.event, "main"))
+
+			if len(tracker.ended) != 1 {
+				t.Fatalf("ended sessions = %d, want 1", len(tracker.ended))
+			}
+			if ended := tracker.ended[0]; !ended.Start.Equal(tt.start) || !ended.LastActivity.Equal(tt.boundary) {
+				t.Errorf("first session %s–%s, want %s–%s", ended.Start, ended.LastActivity, tt.start, tt.boundary)
+			}
+			next := tracker.sessions["/nonexistent/api"]
+			if next == nil || !next.Start.Equal(tt.boundary) || !next.LastActivity.Equal(tt.event) {
+				t.Errorf("second session = %+v, want %s–%s", next, tt.boundary, tt.event)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+	"github.com/liamdn8/auto-worklog-agent/internal/session"
+)
+
+// watchRepoLocked starts watching the git state of a repository with an
+// active session. The caller must hold t.mu.
+func (t *Tracker) watchRepoLocked(repo gitinfo.Info) {
+	if t.gitWatch == nil || repo.Kind == gitinfo.KindRemote || t.watchFailed[repo.Path] || t.gitWatch.Watching(repo.Path) {
+		return
+	}
+	if err := t.gitWatch.Add(repo); err != nil {
+		// Polling still picks up branch changes on the next window event
+		log.Printf("Watch git state of %s: %v", repo.Path, err)
+		t.watchFailed[repo.Path] = true
+	}
+}
+
+// watchingRepo reports whether branch and commit changes of a repository
+// arrive through the git watcher rather than polling.
+func (t *Tracker) watchingRepo(repoPath string) bool {
+	return t.gitWatch != nil && t.gitWatch.Watching(repoPath)
+}
+
+// unwatchInactive stops watching repositories that no longer have a session.
+func (t *Tracker) unwatchInactive() {
+	if t.gitWatch == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, repoPath := range t.gitWatch.Repos() {
+		if _, ok := t.sessions[repoPath]; !ok {
+			t.gitWatch.Remove(repoPath)
+		}
+	}
+}
+
+// gitStateChanged reacts to a checkout or commit in a watched repository: a
+// new branch splits the session as a polled branch change would, and new
+// commits are captured straight away.
+func (t *Tracker) gitStateChanged(repoPath string) {
+	state, err := gitinfo.GetHeadState(repoPath)
+	if err != nil {
+		log.Printf("resolve branch for %s: %v", repoPath, err)
+		return
+	}
+	branch := state.Branch
+
+	t.mu.Lock()
+	sess, ok := t.sessions[repoPath]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	if branch != sess.Branch {
+		evt := repoEvent{
+			repo:      sess.Repo,
+			when:      time.Now(),
+			path:      "[git] HEAD changed",
+			app:       sess.App,
+			category:  sess.Category,
+			project:   sess.Project,
+			component: sess.Component,
+			review:    sess.Review,
+			branch:    branch,
+			operation: state.Operation,
+			headState: true,
+		}
+		t.mu.Unlock()
+		t.recordEvent(evt)
+		return
+	}
+
+	if state.Operation != sess.Operation {
+		if state.Operation != "" {
+			log.Printf("Git %s in progress repo=%s branch=%s", state.Operation, sess.Repo.Name, sess.Branch)
+		}
+		sess.RecordOperation(state.Operation)
+	}
+	before := len(sess.Commits)
+	t.refreshCommitsLocked(sess)
+	if len(sess.Commits) > before {
+		latest := sess.Commits[len(sess.Commits)-1]
+		log.Printf("Commit detected repo=%s branch=%s commit=%.8s message=%q", sess.Repo.Name, sess.Branch, latest.Hash, latest.Message)
+	}
+	t.mu.Unlock()
+}
+
+// refreshCommitsLocked records the commits made since the session started.
+// The caller must hold t.mu.
+func (t *Tracker) refreshCommitsLocked(sess *session.State) {
+	if sess.StartCommit == "" {
+		return
+	}
+	commits, err := gitinfo.GetCommitsSince(sess.Repo.Path, sess.StartCommit)
+	if err == nil && len(commits) > 0 {
+		sess.Commits = commits
+	}
+}
+
+// gitHookEvent is a git hook notification queued for the Run loop.
+type gitHookEvent struct {
+	hook string
+	root string
+	when time.Time
+}
+
+// GitHook queues a notification from an installed git hook for the Run loop,
+// which owns session updates. It is safe to call from other goroutines.
+func (t *Tracker) GitHook(hook, path string) error {
+	switch hook {
+	case "post-commit", "post-checkout", "post-merge", "post-rewrite":
+	default:
+		return fmt.Errorf("unknown git hook %q", hook)
+	}
+	root, err := gitinfo.FindRepoRoot(path)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case t.gitHooks <- gitHookEvent{hook: hook, root: root, when: time.Now()}:
+		return nil
+	default:
+		return fmt.Errorf("too many pending git hook notifications")
+	}
+}
+
+// gitHookRan records a git hook notification. The commit, checkout, merge or
+// rewrite counts as activity on the repository even when no IDE window
+// showed it, so a terminal commit starts or extends a session, a branch
+// switch splits it and new commits are captured at once.
+func (t *Tracker) gitHookRan(evt gitHookEvent) {
+	hook, root := evt.hook, evt.root
+	t.repoMu.RLock()
+	repo, ok := t.repos[root]
+	t.repoMu.RUnlock()
+	if !ok {
+		if gitinfo.Excluded(root) {
+			return
+		}
+		var err error
+		if repo, err = gitinfo.Discover(root); err != nil {
+			log.Printf("discover repo %s: %v", root, err)
+			return
+		}
+	}
+
+	event := repoEvent{repo: repo, when: evt.when, path: "[git] " + hook, category: defaultCategory}
+	t.mu.Lock()
+	if sess, ok := t.sessions[root]; ok {
+		// Keep the session's context; a hook says nothing about it
+		event.repo = sess.Repo
+		event.category = sess.Category
+		event.project = sess.Project
+		event.component = sess.Component
+		event.review = sess.Review
+	}
+	t.mu.Unlock()
+	t.recordEvent(event)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sess, ok := t.sessions[root]
+	if !ok {
+		return
+	}
+	if hook == "post-commit" && len(sess.Commits) == 0 {
+		// A session the commit itself started began at the new commit
+		if head, err := gitinfo.GetCurrentCommitHash(root); err == nil && head == sess.StartCommit {
+			if previous, err := gitinfo.GetPreviousHeadHash(root); err == nil && previous != "" {
+				sess.StartCommit = previous
+			}
+		}
+	}
+	before := len(sess.Commits)
+	t.refreshCommitsLocked(sess)
+	if len(sess.Commits) > before {
+		latest := sess.Commits[len(sess.Commits)-1]
+		log.Printf("Commit detected repo=%s branch=%s commit=%.8s message=%q", sess.Repo.Name, sess.Branch, latest.Hash, latest.Message)
+	}
+}
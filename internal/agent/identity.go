@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+// identityRule overrides the git identity published for repositories at or
+// below root.
+type identityRule struct {
+	root  string
+	name  string
+	email string
+}
+
+// compileIdentityRules orders the rules longest root first, so the most
+// specific one wins.
+func compileIdentityRules(rules []config.IdentityRule) []identityRule {
+	compiled := make([]identityRule, 0, len(rules))
+	for _, rule := range rules {
+		compiled = append(compiled, identityRule{root: rule.Root, name: rule.Name, email: rule.Email})
+	}
+	sort.SliceStable(compiled, func(i, j int) bool { return len(compiled[i].root) > len(compiled[j].root) })
+	return compiled
+}
+
+// identityFor returns the git name and email published for a repository:
+// the identity git resolves, overridden by the most specific identity rule.
+// It warns once per repository when no identity resolves at all.
+func (t *Tracker) identityFor(repo gitinfo.Info) (user, email string) {
+	user, email = repo.User, repo.Email
+	for _, rule := range t.identities {
+		if repo.Path != rule.root && !strings.HasPrefix(repo.Path, rule.root+string(filepath.Separator)) {
+			continue
+		}
+		if rule.name != "" {
+			user = rule.name
+		}
+		if rule.email != "" {
+			email = rule.email
+		}
+		break
+	}
+
+	if user == "" && email == "" {
+		t.identityMu.Lock()
+		if !t.identityWarned[repo.Path] {
+			t.identityWarned[repo.Path] = true
+			log.Printf("No git identity for repo=%s: set user.name and user.email or add a git.identities rule; publishing as unknown", repo.Path)
+		}
+		t.identityMu.Unlock()
+	}
+	return user, email
+}
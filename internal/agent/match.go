@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+// Match tiers, strongest first. Within a tier longer names win.
+const (
+	matchNone = iota
+	matchWord
+	matchToken
+	matchPath
+)
+
+type repoCandidate struct {
+	info   gitinfo.Info
+	tier   int
+	length int
+}
+
+// rankRepos scores every repository against a window title and returns the
+// candidates ordered best first. Ties are broken by path so results are stable.
+func rankRepos(title string, repos map[string]gitinfo.Info) []repoCandidate {
+	lowerTitle := strings.ToLower(title)
+	tokens := titleTokens(lowerTitle)
+
+	candidates := make([]repoCandidate, 0, 4)
+	for _, info := range repos {
+		if c := scoreRepo(lowerTitle, tokens, info); c.tier != matchNone {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.tier != b.tier {
+			return a.tier > b.tier
+		}
+		if a.length != b.length {
+			return a.length > b.length
+		}
+		return a.info.Path < b.info.Path
+	})
+
+	return candidates
+}
+
+func scoreRepo(lowerTitle string, tokens map[string]bool, info gitinfo.Info) repoCandidate {
+	path := strings.ToLower(info.Path)
+	best := repoCandidate{info: info}
+
+	consider := func(tier, length int) {
+		if tier > best.tier || (tier == best.tier && length > best.length) {
+			best.tier = tier
+			best.length = length
+		}
+	}
+
+	if path != "" && containsWord(lowerTitle, path) {
+		consider(matchPath, len(path))
+	}
+
+	for _, name := range []string{strings.ToLower(info.Name), filepath.Base(path)} {
+		if name == "" || name == "." || name == string(filepath.Separator) {
+			continue
+		}
+		if tokens[name] {
+			consider(matchToken, len(name))
+		} else if containsWord(lowerTitle, name) {
+			consider(matchWord, len(name))
+		}
+	}
+
+	return best
+}
+
+// containsWord reports whether word occurs in s without a word character on
+// either side. Like titleTokens it counts hyphens, dots and underscores as
+// part of words, so "api" is found in "main.go — api" but not in
+// "capital.go" or "api-gateway", and "/src/api" not in "/src/apiary".
+func containsWord(s, word string) bool {
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if !wordRuneBefore(s, start) && !wordRuneAfter(s, end) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func wordRuneBefore(s string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return i > 0 && isWordRune(r)
+}
+
+func wordRuneAfter(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return i < len(s) && isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.'
+}
+
+// titleTokens splits a title on the separators IDEs put between file,
+// workspace and app names. Hyphens, dots and underscores stay inside tokens
+// so names like "auto-worklog-agent" survive intact.
+func titleTokens(lowerTitle string) map[string]bool {
+	fields := strings.FieldsFunc(lowerTitle, func(r rune) bool {
+		switch r {
+		case '-', '_', '.':
+			return false
+		case '—', '–', '|', '/', '\\', '(', ')', '[', ']', '{', '}', ':', ',', '•', '·', '*', '"', '\'':
+			return true
+		}
+		return unicode.IsSpace(r)
+	})
+
+	tokens := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		tokens[field] = true
+	}
+	return tokens
+}
+
+// ambiguous reports whether the top two candidates are indistinguishable by score.
+func ambiguous(candidates []repoCandidate) bool {
+	return len(candidates) > 1 &&
+		candidates[0].tier == candidates[1].tier &&
+		candidates[0].length == candidates[1].length
+}
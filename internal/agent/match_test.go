@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+func TestRankReposWordBoundaries(t *testing.T) {
+	repos := map[string]gitinfo.Info{
+		"/src/api": {Name: "api", Path: "/src/api"},
+	}
+
+	tests := []struct {
+		title string
+		ok    bool
+	}{
+		{title: "handler.go — api", ok: true},
+		{title: "api-gateway.yaml — deploy", ok: false},
+		{title: "main.go - /src/api/cmd - Vim", ok: true},
+		{title: "main.go - /src/apiary/cmd - Vim", ok: false},
+		{title: "capital.go — other-project", ok: false},
+		{title: "rapid api2 notes", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := rankRepos(tt.title, repos); (len(got) > 0) != tt.ok {
+				t.Errorf("rankRepos(%q) matched %d repos, want match %v", tt.title, len(got), tt.ok)
+			}
+		})
+	}
+}
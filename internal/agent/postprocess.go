@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/session"
+)
+
+// endSessionLocked removes an active session and holds it for the merge window.
+// The caller must hold t.mu.
+func (t *Tracker) endSessionLocked(repoKey string, sess *session.State) {
+	if t.sessions[repoKey] == sess {
+		delete(t.sessions, repoKey)
+	}
+	t.ended = append(t.ended, sess)
+}
+
+// resumeEndedLocked returns the most recently ended session for the same repo,
+// branch and context when the gap since it ended is below the merge threshold
+// and both fall on the same reporting day. The caller must hold t.mu.
+func (t *Tracker) resumeEndedLocked(repoKey, branch string, evt repoEvent) *session.State {
+	if t.mergeGap <= 0 {
+		return nil
+	}
+
+	idx := -1
+	for i, sess := range t.ended {
+		if sess.Repo.Path != repoKey || sess.Branch != branch {
+			continue
+		}
+		if contextChange(sess, evt) != "" {
+			continue
+		}
+		if idx < 0 || sess.LastActivity.After(t.ended[idx].LastActivity) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return nil
+	}
+
+	prev := t.ended[idx]
+	if evt.when.Sub(prev.LastActivity) >= t.mergeGap || t.crossesDay(prev.Start, evt.when) {
+		return nil
+	}
+
+	t.ended = append(t.ended[:idx], t.ended[idx+1:]...)
+	return prev
+}
+
+// flushEnded publishes ended sessions whose merge window has passed, or all
+// of them when force is set. Sessions shorter than the minimum are dropped.
+func (t *Tracker) flushEnded(ctx context.Context, force bool) {
+	t.mu.Lock()
+	due := make([]*session.State, 0, len(t.ended))
+	kept := t.ended[:0]
+	for _, sess := range t.ended {
+		if force || time.Since(sess.LastActivity) >= t.mergeGap {
+			due = append(due, sess)
+		} else {
+			kept = append(kept, sess)
+		}
+	}
+	t.ended = kept
+	t.mu.Unlock()
+
+	for _, sess := range due {
+		if sess.Duration() < t.minDuration {
+			log.Printf("Dropping short session repo=%s branch=%s duration=%s (minimum %s)",
+				sess.Repo.Name, sess.Branch, sess.Duration(), t.minDuration)
+			continue
+		}
+
+		log.Printf("Publishing ended session repo=%s branch=%s duration=%s events=%d",
+			sess.Repo.Name, sess.Branch, sess.Duration(), sess.Events)
+		if err := t.publishSession(ctx, sess); err != nil {
+			log.Printf("publish session %s: %v", sess.Repo.Path, err)
+			// Keep it for the next flush
+			t.mu.Lock()
+			t.ended = append(t.ended, sess)
+			t.mu.Unlock()
+		}
+	}
+}
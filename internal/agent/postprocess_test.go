@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFlushDropsSessionsBelowMinDuration(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		duration  time.Duration
+		published int
+	}{
+		{name: "below minimum", duration: 10 * time.Second, published: 0},
+		{name: "at minimum", duration: 30 * time.Second, published: 1},
+		{name: "above minimum", duration: 5 * time.Minute, published: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, aw := newTestTracker(t)
+			tracker.recordEvent(testEvent(start, "main"))
+			tracker.recordEvent(testEvent(start.Add(tt.duration), "main"))
+
+			tracker.flushAll(context.Background())
+
+			if got := len(aw.published()); got != tt.published {
+				t.Errorf("published %d sessions, want %d", got, tt.published)
+			}
+			if len(tracker.ended) != 0 || len(tracker.sessions) != 0 {
+				t.Errorf("sessions left after flush: %d active, %d ended", len(tracker.sessions), len(tracker.ended))
+			}
+		})
+	}
+}
+
+func TestResumeWithinMergeGap(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	switched := start.Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		back    time.Time
+		resumed bool
+	}{
+		{name: "inside gap", back: switched.Add(90 * time.Second), resumed: true},
+		{name: "at gap", back: switched.Add(2 * time.Minute), resumed: false},
+		{name: "outside gap", back: switched.Add(5 * time.Minute), resumed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, _ := newTestTracker(t)
+			tracker.recordEvent(testEvent(start, "main"))
+			tracker.recordEvent(testEvent(switched, "main"))
+			// A branch switch ends the session on main
+			tracker.recordEvent(testEvent(switched.Add(5*time.Second), "fix"))
+			tracker.recordEvent(testEvent(tt.back, "main"))
+
+			sess := tracker.sessions["/nonexistent/api"]
+			if sess == nil || sess.Branch != "main" {
+				t.Fatalf("active session = %+v, want one on main", sess)
+			}
+			if resumed := sess.Start.Equal(start); resumed != tt.resumed {
+				t.Errorf("session starts at %s, resumed = %v, want %v", sess.Start, resumed, tt.resumed)
+			}
+			for _, ended := range tracker.ended {
+				if ended == sess {
+					t.Errorf("resumed session is still held as ended")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+	"github.com/liamdn8/auto-worklog-agent/internal/watcher"
+)
+
+// processCacheTTL bounds how often an IDE's process tree is re-inspected.
+const processCacheTTL = 30 * time.Second
+
+type processRepos struct {
+	at    time.Time
+	repos []gitinfo.Info
+}
+
+// processRepoCandidates maps an IDE window to the repositories it has open,
+// via command-line workspace arguments, working directories and open files
+// of its process and the process's children. The window's own command line
+// comes first and is all there is where the process tree can't be read.
+// Results are cached per PID. Only the window loop goroutine calls this.
+func (t *Tracker) processRepoCandidates(window watcher.WindowInfo) []gitinfo.Info {
+	pid := window.PID
+	if pid <= 0 {
+		return nil
+	}
+
+	if cached, ok := t.procCache[pid]; ok && time.Since(cached.at) < processCacheTTL {
+		return cached.repos
+	}
+
+	// Drop stale entries so exited processes don't accumulate
+	for cachedPID, cached := range t.procCache {
+		if time.Since(cached.at) >= processCacheTTL {
+			delete(t.procCache, cachedPID)
+		}
+	}
+
+	paths := cmdlinePaths(window.Cmdline)
+	tree, err := watcher.ProcessPaths(pid)
+	if err != nil && t.cfg.Verbose {
+		log.Printf("Process paths for pid %d: %v", pid, err)
+	}
+	paths = append(paths, tree...)
+
+	t.repoMu.RLock()
+	seen := make(map[string]bool)
+	repos := make([]gitinfo.Info, 0, 2)
+	for _, path := range paths {
+		repo, ok := repoContaining(path, t.repos)
+		if !ok || seen[repo.Path] {
+			continue
+		}
+		seen[repo.Path] = true
+		repos = append(repos, repo)
+	}
+	t.repoMu.RUnlock()
+
+	t.procCache[pid] = processRepos{at: time.Now(), repos: repos}
+	return repos
+}
+
+// cmdlinePaths returns the existing absolute paths named by command-line
+// arguments, either on their own or as the value of "--flag=path".
+func cmdlinePaths(cmdline []string) []string {
+	var paths []string
+	for _, arg := range cmdline {
+		if _, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(arg, "-") {
+			arg = value
+		}
+		if !filepath.IsAbs(arg) {
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			paths = append(paths, filepath.Clean(arg))
+		}
+	}
+	return paths
+}
+
+// matchRemote finds the discovered repository with a remote whose normalized
+// URL is the given "org/repo" path on the forge host, or ends with it when
+// the host is unknown. A match on origin beats one on another remote, such as
+// the upstream of a fork; clones are tie-broken by path.
+func (t *Tracker) matchRemote(forge, remotePath string) (gitinfo.Info, bool) {
+	remotePath = strings.Trim(strings.ToLower(remotePath), "/")
+	if forge != "" {
+		want := forge + "/" + remotePath
+		return t.bestRemoteMatch(func(normalized string) bool { return normalized == want })
+	}
+	suffix := "/" + remotePath
+	return t.bestRemoteMatch(func(normalized string) bool {
+		return strings.HasSuffix(normalized, suffix)
+	})
+}
+
+// bestRemoteMatch returns the repository with a remote accepted by match,
+// preferring origin matches and then the lowest path.
+func (t *Tracker) bestRemoteMatch(match func(normalized string) bool) (gitinfo.Info, bool) {
+	t.repoMu.RLock()
+	defer t.repoMu.RUnlock()
+
+	var best gitinfo.Info
+	bestRank := -1
+	for _, info := range t.repos {
+		rank := -1
+		if match(gitinfo.NormalizeRemoteURL(info.Remote)) {
+			rank = 1
+		} else {
+			for _, url := range info.Remotes {
+				if match(gitinfo.NormalizeRemoteURL(url)) {
+					rank = 0
+					break
+				}
+			}
+		}
+		if rank < 0 {
+			continue
+		}
+		if rank > bestRank || (rank == bestRank && info.Path < best.Path) {
+			best = info
+			bestRank = rank
+		}
+	}
+	return best, bestRank >= 0
+}
+
+// resolveRepo attributes a matched window to a repository. Terminal cwd and
+// paths shown in the title are most specific; the IDE process's open
+// repositories come next, disambiguated by the title when there are several;
+// ranked title matching over all repositories is the fallback.
+func (t *Tracker) resolveRepo(window watcher.WindowInfo, match appMatch) (gitinfo.Info, bool) {
+	// Hosted pages such as pull requests identify the repo by remote only
+	if match.parsed.Remote != "" {
+		return t.matchRemote(match.parsed.Forge, match.parsed.Remote)
+	}
+
+	if match.rule.processCwd {
+		if repo, ok := t.matchProcessCwd(window.PID); ok {
+			return repo, true
+		}
+	}
+
+	if repo, ok := t.matchTitlePaths(match.parsed); ok {
+		return repo, true
+	}
+
+	if !match.rule.processCwd {
+		candidates := t.processRepoCandidates(window)
+		switch {
+		case len(candidates) == 1:
+			return candidates[0], true
+		case len(candidates) > 1:
+			byPath := make(map[string]gitinfo.Info, len(candidates))
+			for _, repo := range candidates {
+				byPath[repo.Path] = repo
+			}
+			if repo, ok := t.matchTitleIn(window.Title, match.parsed, byPath); ok {
+				return repo, true
+			}
+		}
+	}
+
+	return t.matchCachedRepo(window.Title, match.parsed)
+}
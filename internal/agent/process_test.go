@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+	"github.com/liamdn8/auto-worklog-agent/internal/watcher"
+)
+
+func TestMatchRemoteUsesForgeHost(t *testing.T) {
+	tracker := &Tracker{repos: map[string]gitinfo.Info{
+		"/src/github/api": {Path: "/src/github/api", Remote: "git@github.com:acme/api.git"},
+		"/src/gitlab/api": {Path: "/src/gitlab/api", Remote: "https://gitlab.example.com/acme/api.git"},
+	}}
+	rules, err := compileAppRules(config.AppsConfig{IncludeDefaults: true, GitLabHost: "gitlab.example.com"})
+	if err != nil {
+		t.Fatalf("compileAppRules: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "github", title: "Fix X · Pull Request #12 · acme/api · GitHub — Mozilla Firefox", want: "/src/github/api"},
+		{name: "gitlab", title: "Fix X (!3) · Merge requests · acme / api · GitLab — Mozilla Firefox", want: "/src/gitlab/api"},
+		{name: "bitbucket", title: "acme / api / Pull Request #7: Fix X — Bitbucket — Mozilla Firefox", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := matchApp(rules, watcher.WindowInfo{App: "firefox", Title: tt.title})
+			if !ok || match.parsed.Remote == "" {
+				t.Fatalf("matchApp(%q) found no remote", tt.title)
+			}
+			got, _ := tracker.matchRemote(match.parsed.Forge, match.parsed.Remote)
+			if got.Path != tt.want {
+				t.Errorf("matchRemote(%q, %q) = %q, want %q", match.parsed.Forge, match.parsed.Remote, got.Path, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+const (
+	// remoteBranchTTL bounds how often a remote branch command is re-run.
+	remoteBranchTTL = 30 * time.Second
+	// remoteBranchTimeout stops a hung ssh or docker command from stalling the window loop.
+	remoteBranchTimeout = 5 * time.Second
+)
+
+// remoteDevRule maps a remote development workspace to a repository.
+type remoteDevRule struct {
+	kind          string
+	host          string
+	localMirror   string
+	remoteURL     string
+	branchCommand string
+}
+
+type remoteBranch struct {
+	at     time.Time
+	branch string
+}
+
+func compileRemoteDevRules(cfgs []config.RemoteDevRule) ([]remoteDevRule, error) {
+	rules := make([]remoteDevRule, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		host := strings.ToLower(cfg.Host)
+		if host == "" {
+			host = "*"
+		}
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, fmt.Errorf("remoteDev rule %d: host %q: %w", i, cfg.Host, err)
+		}
+		if cfg.LocalMirror == "" && cfg.RemoteURL == "" {
+			return nil, fmt.Errorf("remoteDev rule %d: set localMirror or remoteURL", i)
+		}
+		rules = append(rules, remoteDevRule{
+			kind:          remoteKind(cfg.Kind),
+			host:          host,
+			localMirror:   cfg.LocalMirror,
+			remoteURL:     cfg.RemoteURL,
+			branchCommand: cfg.BranchCommand,
+		})
+	}
+	return rules, nil
+}
+
+func (rule remoteDevRule) matches(parsed TitleInfo) bool {
+	if rule.kind != "" && rule.kind != parsed.RemoteKind {
+		return false
+	}
+	matched, _ := path.Match(rule.host, strings.ToLower(parsed.RemoteHost))
+	return matched
+}
+
+// expand fills {host} and {workspace} in a rule template, passing each value
+// through quote.
+func (rule remoteDevRule) expand(tmpl string, parsed TitleInfo, quote func(string) string) string {
+	return strings.NewReplacer(
+		"{host}", quote(parsed.RemoteHost),
+		"{workspace}", quote(parsed.Workspace),
+	).Replace(tmpl)
+}
+
+// matchRemoteDev resolves a remote workspace through the first matching rule:
+// its local mirror, then a discovered clone with the same remote URL, then a
+// repository identified by the remote URL alone.
+func (t *Tracker) matchRemoteDev(parsed TitleInfo) (gitinfo.Info, remoteDevRule, bool) {
+	if parsed.Workspace == "" {
+		return gitinfo.Info{}, remoteDevRule{}, false
+	}
+
+	for _, rule := range t.remoteDev {
+		if !rule.matches(parsed) {
+			continue
+		}
+
+		if rule.localMirror != "" {
+			dir := rule.expand(rule.localMirror, parsed, identity)
+			if !strings.Contains(rule.localMirror, "{workspace}") {
+				dir = filepath.Join(dir, parsed.Workspace)
+			}
+			t.repoMu.RLock()
+			repo, ok := repoContaining(dir, t.repos)
+			t.repoMu.RUnlock()
+			if ok {
+				return repo, rule, true
+			}
+		}
+
+		if rule.remoteURL != "" {
+			remote := rule.expand(rule.remoteURL, parsed, identity)
+			if repo, ok := t.matchRemoteURL(remote); ok {
+				return repo, rule, true
+			}
+			return virtualRemoteRepo(parsed, remote), rule, true
+		}
+	}
+	return gitinfo.Info{}, remoteDevRule{}, false
+}
+
+// matchRemoteURL finds a discovered clone of the given remote URL.
+func (t *Tracker) matchRemoteURL(remote string) (gitinfo.Info, bool) {
+	want := gitinfo.NormalizeRemoteURL(remote)
+	if want == "" {
+		return gitinfo.Info{}, false
+	}
+	return t.bestRemoteMatch(func(normalized string) bool {
+		return normalized == want
+	})
+}
+
+// virtualRemoteRepo stands in for a remote workspace with no local clone. Its
+// path is a URL-like key so sessions of different hosts stay apart.
+func virtualRemoteRepo(parsed TitleInfo, remote string) gitinfo.Info {
+	user, email := gitinfo.GlobalIdentity()
+	name := strings.TrimSuffix(path.Base(gitinfo.NormalizeRemoteURL(remote)), ".git")
+	if name == "" || name == "." || name == "/" {
+		name = parsed.Workspace
+	}
+	return gitinfo.Info{
+		Path:   fmt.Sprintf("%s://%s/%s", parsed.RemoteKind, parsed.RemoteHost, parsed.Workspace),
+		Name:   name,
+		ID:     gitinfo.RemoteID(remote),
+		User:   user,
+		Email:  email,
+		Remote: remote,
+		Kind:   gitinfo.KindRemote,
+	}
+}
+
+// remoteBranchFor runs the rule's branch command, caching the result per
+// workspace. Only the window loop goroutine calls this.
+func (t *Tracker) remoteBranchFor(rule remoteDevRule, parsed TitleInfo) string {
+	if rule.branchCommand == "" {
+		return ""
+	}
+
+	command := rule.expand(rule.branchCommand, parsed, shellQuote)
+	if cached, ok := t.remoteBranches[command]; ok && time.Since(cached.at) < remoteBranchTTL {
+		return cached.branch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteBranchTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+	branch := strings.TrimSpace(string(out))
+	if err != nil {
+		log.Printf("Remote branch command for %s on %s failed: %v", parsed.Workspace, parsed.RemoteHost, err)
+		branch = ""
+	}
+	if i := strings.IndexByte(branch, '\n'); i >= 0 {
+		branch = branch[:i]
+	}
+
+	t.remoteBranches[command] = remoteBranch{at: time.Now(), branch: branch}
+	return branch
+}
+
+func identity(s string) string { return s }
+
+// shellQuote quotes title-derived values so they cannot inject shell syntax.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
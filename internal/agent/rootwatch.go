@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+// startRootWatch watches the scan roots for new and deleted repositories.
+// It returns nil when root watching is disabled or unavailable; the periodic
+// rescan still picks changes up.
+func (t *Tracker) startRootWatch() *gitinfo.RootWatcher {
+	if !t.cfg.Git.WatchRoots || len(t.cfg.Git.Roots) == 0 {
+		return nil
+	}
+	watcher, err := gitinfo.NewRootWatcher(t.discoveryOptions(), t.cfg.Git.MaxRootWatches)
+	if err != nil {
+		log.Printf("Root watching unavailable, new repositories are picked up by the periodic rescan: %v", err)
+		return nil
+	}
+	return watcher
+}
+
+// rootChanged adds a repository that appeared under a root, with its
+// submodules, or drops every repository at or below a deleted directory.
+func (t *Tracker) rootChanged(evt gitinfo.RootEvent) {
+	if evt.Removed {
+		prefix := evt.Path + string(filepath.Separator)
+		t.repoMu.Lock()
+		for path := range t.repos {
+			if path == evt.Path || strings.HasPrefix(path, prefix) {
+				delete(t.repos, path)
+				log.Printf("Repository removed: %s", path)
+			}
+		}
+		t.repoMu.Unlock()
+		return
+	}
+
+	t.repoMu.RLock()
+	_, known := t.repos[evt.Path]
+	t.repoMu.RUnlock()
+	if known {
+		return
+	}
+
+	info, err := gitinfo.Discover(evt.Path)
+	if err != nil {
+		log.Printf("discover repo %s: %v", evt.Path, err)
+		return
+	}
+	found := []gitinfo.Info{info}
+	for _, sub := range gitinfo.TrackedSubmodules(evt.Path) {
+		if subInfo, err := gitinfo.Discover(sub); err == nil {
+			found = append(found, subInfo)
+		}
+	}
+
+	t.repoMu.Lock()
+	for _, repo := range found {
+		t.repos[repo.Path] = repo
+	}
+	t.repoMu.Unlock()
+	log.Printf("New repository detected: %s (%s)", info.Path, info.Name)
+}
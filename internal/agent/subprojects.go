@@ -0,0 +1,252 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+)
+
+// codeownersLocations are checked in the order GitHub and GitLab use.
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"}
+
+// subprojectResolver attributes files inside a monorepo to a project and component.
+type subprojectResolver struct {
+	rules []subprojectRule
+
+	mu         sync.Mutex
+	codeowners map[string]*codeownersFile // keyed by repo path
+}
+
+type subprojectRule struct {
+	repo       string
+	prefixes   []prefixMapping
+	manifests  []string
+	codeowners bool
+}
+
+type prefixMapping struct {
+	prefix  string
+	project string
+}
+
+type codeownersFile struct {
+	path    string
+	modTime time.Time
+	entries []codeownersEntry
+}
+
+type codeownersEntry struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+func newSubprojectResolver(cfgs []config.SubprojectRule) (*subprojectResolver, error) {
+	resolver := &subprojectResolver{codeowners: make(map[string]*codeownersFile)}
+
+	for i, cfg := range cfgs {
+		if cfg.Repo == "" {
+			return nil, fmt.Errorf("subproject rule %d: repo is required", i)
+		}
+		if _, err := filepath.Match(cfg.Repo, ""); err != nil {
+			return nil, fmt.Errorf("subproject rule %d: repo %q: %w", i, cfg.Repo, err)
+		}
+
+		rule := subprojectRule{repo: cfg.Repo, manifests: cfg.Manifests, codeowners: cfg.Codeowners}
+		for prefix, project := range cfg.Prefixes {
+			prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+			rule.prefixes = append(rule.prefixes, prefixMapping{prefix: prefix, project: project})
+		}
+		if len(rule.prefixes) == 0 && len(rule.manifests) == 0 && !rule.codeowners {
+			return nil, fmt.Errorf("subproject rule %d: set prefixes, manifests or codeowners", i)
+		}
+
+		resolver.rules = append(resolver.rules, rule)
+	}
+
+	return resolver, nil
+}
+
+// resolve returns the project and component for a file given relative to the
+// repository root. Empty results mean the file could not be attributed.
+func (r *subprojectResolver) resolve(repo gitinfo.Info, file string) (project, component string) {
+	if file == "" || filepath.IsAbs(file) || !strings.Contains(file, "/") {
+		// Bare file names from titles don't say where in the repo they live
+		return "", ""
+	}
+	file = path.Clean(filepath.ToSlash(file))
+
+	for _, rule := range r.rules {
+		if !rule.appliesTo(repo) {
+			continue
+		}
+		if project == "" {
+			project = rule.prefixProject(file)
+		}
+		if project == "" && len(rule.manifests) > 0 {
+			project = nearestManifestDir(repo.Path, file, rule.manifests)
+		}
+		if component == "" && rule.codeowners {
+			component = r.owner(repo.Path, file)
+		}
+	}
+	return project, component
+}
+
+func (rule subprojectRule) appliesTo(repo gitinfo.Info) bool {
+	if rule.repo == repo.Name || rule.repo == repo.Path {
+		return true
+	}
+	matched, _ := filepath.Match(rule.repo, repo.Path)
+	return matched
+}
+
+// prefixProject picks the longest configured directory prefix containing file.
+func (rule subprojectRule) prefixProject(file string) string {
+	best := -1
+	project := ""
+	for _, m := range rule.prefixes {
+		if m.prefix != "" && file != m.prefix && !strings.HasPrefix(file, m.prefix+"/") {
+			continue
+		}
+		if len(m.prefix) > best {
+			best = len(m.prefix)
+			project = m.project
+		}
+	}
+	return project
+}
+
+// nearestManifestDir walks up from the file's directory to the repository
+// root and returns the first directory holding one of the manifests.
+func nearestManifestDir(root, file string, manifests []string) string {
+	dir := path.Dir(file)
+	for dir != "." && dir != "/" {
+		for _, manifest := range manifests {
+			if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(dir), manifest)); err == nil {
+				return dir
+			}
+		}
+		dir = path.Dir(dir)
+	}
+	return ""
+}
+
+// owner returns the first owner of the last CODEOWNERS pattern matching file.
+func (r *subprojectResolver) owner(root, file string) string {
+	co := r.loadCodeowners(root)
+	if co == nil {
+		return ""
+	}
+	for i := len(co.entries) - 1; i >= 0; i-- {
+		entry := co.entries[i]
+		if entry.pattern.MatchString(file) {
+			if len(entry.owners) == 0 {
+				return ""
+			}
+			return entry.owners[0]
+		}
+	}
+	return ""
+}
+
+// loadCodeowners returns the parsed CODEOWNERS of a repo, reparsing it when it changes.
+func (r *subprojectResolver) loadCodeowners(root string) *codeownersFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, loc := range codeownersLocations {
+		full := filepath.Join(root, loc)
+		stat, err := os.Stat(full)
+		if err != nil {
+			continue
+		}
+
+		if cached, ok := r.codeowners[root]; ok && cached.path == full && cached.modTime.Equal(stat.ModTime()) {
+			return cached
+		}
+
+		parsed, err := parseCodeowners(full)
+		if err != nil {
+			return nil
+		}
+		parsed.modTime = stat.ModTime()
+		r.codeowners[root] = parsed
+		return parsed
+	}
+
+	delete(r.codeowners, root)
+	return nil
+}
+
+func parseCodeowners(file string) (*codeownersFile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	co := &codeownersFile{path: file}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		pattern, err := codeownersPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		co.entries = append(co.entries, codeownersEntry{pattern: pattern, owners: fields[1:]})
+	}
+	return co, scanner.Err()
+}
+
+// codeownersPattern converts a gitignore-style CODEOWNERS pattern to a regex
+// over slash-separated paths relative to the repository root.
+func codeownersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("/?")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A pattern naming a directory covers everything below it
+	b.WriteString("(/.*)?$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// TitleInfo is what a title parser extracts from an IDE window title.
+type TitleInfo struct {
+	Parser        string // name of the parser that recognised the title
+	Workspace     string // project or folder display name
+	WorkspacePath string // absolute workspace directory when the title shows one
+	File          string // focused file, absolute or relative to the workspace
+	Dirty         bool   // unsaved changes indicator
+	Remote        string // "org/repo" path of a hosted repository, e.g. from a PR page
+	Forge         string // host Remote lives on, e.g. github.com; empty when unknown
+	Review        string // pull or merge request number being reviewed
+	RemoteKind    string // remote development kind: ssh, dev-container, wsl, codespaces
+	RemoteHost    string // remote host, container or distribution name
+}
+
+// titleParser extracts workspace and file details from one IDE's title format.
+type titleParser struct {
+	name  string
+	apps  []string // lowercase substrings of the window app/class name
+	parse func(title string) (TitleInfo, bool)
+}
+
+// titleParsers is the registry consulted for every window title, in order.
+var titleParsers = []titleParser{
+	{name: "vscode", apps: []string{"code", "vscodium", "code-oss"}, parse: parseVSCodeTitle},
+	{name: "jetbrains", apps: []string{"jetbrains", "idea", "goland", "pycharm", "webstorm", "phpstorm", "clion", "rider", "rubymine", "dataspell", "android-studio"}, parse: parseJetBrainsTitle},
+	{name: "vim", apps: []string{"vim", "nvim", "neovim", "gvim"}, parse: parseVimTitle},
+	{name: "emacs", apps: []string{"emacs"}, parse: parseEmacsTitle},
+	{name: "sublime", apps: []string{"sublime"}, parse: parseSublimeTitle},
+}
+
+// parseTitle runs the parser registered for the app, then falls back to any
+// parser whose title signature matches (terminal vim reports the terminal app).
+func parseTitle(app, title string) (TitleInfo, bool) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return TitleInfo{}, false
+	}
+
+	lowerApp := strings.ToLower(app)
+	for _, p := range titleParsers {
+		for _, candidate := range p.apps {
+			if strings.Contains(lowerApp, candidate) {
+				if info, ok := p.parse(title); ok {
+					info.Parser = p.name
+					return info, true
+				}
+			}
+		}
+	}
+
+	for _, p := range titleParsers {
+		if info, ok := p.parse(title); ok {
+			info.Parser = p.name
+			return info, true
+		}
+	}
+
+	return TitleInfo{}, false
+}
+
+var (
+	vscodeSuffix    = regexp.MustCompile(`\s+[-—]\s+(Visual Studio Code(?: - Insiders)?|Code - OSS|VSCodium)$`)
+	vscodeSeparator = regexp.MustCompile(`\s+[—-]\s+`)
+	workspaceSuffix = regexp.MustCompile(`\s+\(Workspace\)$`)
+	remoteSuffix    = regexp.MustCompile(`\s+\[([^\]:]+):\s*([^\]]+)\]$`)
+)
+
+// parseVSCodeTitle handles "● file — folder — Visual Studio Code".
+func parseVSCodeTitle(title string) (TitleInfo, bool) {
+	loc := vscodeSuffix.FindStringIndex(title)
+	if loc == nil {
+		return TitleInfo{}, false
+	}
+
+	var info TitleInfo
+	rest := title[:loc[0]]
+	if trimmed := strings.TrimPrefix(rest, "● "); trimmed != rest {
+		info.Dirty = true
+		rest = trimmed
+	}
+
+	parts := vscodeSeparator.Split(rest, -1)
+	workspace := parts[len(parts)-1]
+	if m := remoteSuffix.FindStringSubmatch(workspace); m != nil {
+		info.RemoteKind = remoteKind(m[1])
+		info.RemoteHost = strings.TrimSpace(m[2])
+		workspace = workspace[:len(workspace)-len(m[0])]
+	}
+	workspace = workspaceSuffix.ReplaceAllString(workspace, "")
+	info.Workspace = strings.TrimSpace(workspace)
+	if len(parts) > 1 {
+		info.File = strings.TrimSpace(parts[0])
+	}
+
+	return info, info.Workspace != ""
+}
+
+var jetbrainsProduct = regexp.MustCompile(`^(IntelliJ IDEA|GoLand|PyCharm|WebStorm|PhpStorm|CLion|Rider|RubyMine|DataSpell|DataGrip|Android Studio|RustRover)\b`)
+
+// parseJetBrainsTitle handles "project – path/file" and the older
+// "project [~/path] – …/file – Product" format.
+func parseJetBrainsTitle(title string) (TitleInfo, bool) {
+	parts := strings.Split(title, " – ")
+	if len(parts) < 2 {
+		return TitleInfo{}, false
+	}
+	if jetbrainsProduct.MatchString(strings.TrimSpace(parts[len(parts)-1])) {
+		parts = parts[:len(parts)-1]
+	}
+
+	var info TitleInfo
+	project := strings.TrimSpace(parts[0])
+	if open := strings.LastIndex(project, " ["); open >= 0 && strings.HasSuffix(project, "]") {
+		info.WorkspacePath = expandHome(project[open+2 : len(project)-1])
+		project = project[:open]
+	}
+	info.Workspace = project
+
+	if len(parts) > 1 {
+		file := strings.TrimSpace(parts[len(parts)-1])
+		if trimmed := strings.TrimPrefix(file, "*"); trimmed != file {
+			info.Dirty = true
+			file = trimmed
+		}
+		file = strings.TrimPrefix(file, "…/")
+		file = strings.TrimPrefix(file, ".../")
+		info.File = file
+	}
+
+	return info, info.Workspace != ""
+}
+
+var vimTitle = regexp.MustCompile(`^(.+?)((?:\s[-+=]+)*)\s\((.+)\)\s-\s(?i:g?n?vim|neovim)\d*$`)
+
+// parseVimTitle handles the default titlestring "file.go + (~/src/proj) - NVIM".
+func parseVimTitle(title string) (TitleInfo, bool) {
+	m := vimTitle.FindStringSubmatch(title)
+	if m == nil {
+		return TitleInfo{}, false
+	}
+
+	dir := expandHome(m[3])
+	info := TitleInfo{
+		Dirty: strings.Contains(m[2], "+"),
+		File:  filepath.Join(dir, m[1]),
+	}
+	if filepath.IsAbs(dir) {
+		info.WorkspacePath = dir
+	}
+	info.Workspace = filepath.Base(dir)
+
+	return info, true
+}
+
+var emacsTitle = regexp.MustCompile(`^(.+?)\s+-\s+(?:GNU\s+)?Emacs(?:\s+at\s+\S+)?$`)
+
+// parseEmacsTitle handles the default frame title "file.go - GNU Emacs at host".
+func parseEmacsTitle(title string) (TitleInfo, bool) {
+	m := emacsTitle.FindStringSubmatch(title)
+	if m == nil {
+		return TitleInfo{}, false
+	}
+
+	buffer := strings.TrimSpace(m[1])
+	var info TitleInfo
+	if strings.HasPrefix(buffer, "*") && strings.HasSuffix(buffer, "*") && len(buffer) > 1 {
+		// Special buffers such as *scratch* carry no file
+		return info, false
+	}
+	info.File = expandHome(buffer)
+	if filepath.IsAbs(info.File) {
+		info.WorkspacePath = filepath.Dir(info.File)
+		info.Workspace = filepath.Base(info.WorkspacePath)
+	}
+
+	return info, true
+}
+
+var sublimeTitle = regexp.MustCompile(`^(.+?)(\s•)?(?:\s\(([^()]+)\))?\s-\sSublime Text(?:\s\d+)?(?:\s\(UNREGISTERED\))?$`)
+
+// parseSublimeTitle handles "file.go (project) - Sublime Text" and the "•" dirty marker.
+func parseSublimeTitle(title string) (TitleInfo, bool) {
+	m := sublimeTitle.FindStringSubmatch(title)
+	if m == nil {
+		return TitleInfo{}, false
+	}
+
+	file := m[1]
+	info := TitleInfo{Dirty: m[2] != ""}
+	if trimmed := strings.TrimPrefix(file, "• "); trimmed != file {
+		info.Dirty = true
+		file = trimmed
+	}
+	info.File = expandHome(file)
+	info.Workspace = m[3]
+	if info.Workspace == "" && filepath.IsAbs(info.File) {
+		info.Workspace = filepath.Base(filepath.Dir(info.File))
+	}
+
+	return info, true
+}
+
+// remoteKind normalises VS Code remote labels such as "SSH" or "Dev Container".
+func remoteKind(label string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(label)), " ", "-")
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
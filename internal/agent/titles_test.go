@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTitle(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		app   string
+		title string
+		want  TitleInfo
+		ok    bool
+	}{
+		{
+			name:  "vscode file and folder",
+			app:   "Code",
+			title: "tracker.go — auto-worklog-agent — Visual Studio Code",
+			want:  TitleInfo{Parser: "vscode", Workspace: "auto-worklog-agent", File: "tracker.go"},
+			ok:    true,
+		},
+		{
+			name:  "vscode dirty with hyphen separators",
+			app:   "code",
+			title: "● main.go - api - Visual Studio Code",
+			want:  TitleInfo{Parser: "vscode", Workspace: "api", File: "main.go", Dirty: true},
+			ok:    true,
+		},
+		{
+			name:  "vscode folder only",
+			app:   "Code",
+			title: "platform — Visual Studio Code",
+			want:  TitleInfo{Parser: "vscode", Workspace: "platform"},
+			ok:    true,
+		},
+		{
+			name:  "vscode multi-root workspace",
+			app:   "Code - OSS",
+			title: "README.md - services (Workspace) - Code - OSS",
+			want:  TitleInfo{Parser: "vscode", Workspace: "services", File: "README.md"},
+			ok:    true,
+		},
+		{
+			name:  "vscode remote ssh",
+			app:   "Code",
+			title: "handler.go — backend [SSH: buildbox] — Visual Studio Code",
+			want:  TitleInfo{Parser: "vscode", Workspace: "backend", File: "handler.go", RemoteKind: "ssh", RemoteHost: "buildbox"},
+			ok:    true,
+		},
+		{
+			name:  "vscode dev container",
+			app:   "Code",
+			title: "● app.py — web [Dev Container: python-3] — Visual Studio Code",
+			want:  TitleInfo{Parser: "vscode", Workspace: "web", File: "app.py", Dirty: true, RemoteKind: "dev-container", RemoteHost: "python-3"},
+			ok:    true,
+		},
+		{
+			name:  "vscode wsl folder",
+			app:   "Code",
+			title: "tools [WSL: Ubuntu-22.04] — Visual Studio Code",
+			want:  TitleInfo{Parser: "vscode", Workspace: "tools", RemoteKind: "wsl", RemoteHost: "Ubuntu-22.04"},
+			ok:    true,
+		},
+		{
+			name:  "jetbrains project and file",
+			app:   "jetbrains-goland",
+			title: "auto-worklog-agent – internal/agent/tracker.go",
+			want:  TitleInfo{Parser: "jetbrains", Workspace: "auto-worklog-agent", File: "internal/agent/tracker.go"},
+			ok:    true,
+		},
+		{
+			name:  "jetbrains legacy format with path and product",
+			app:   "jetbrains-idea",
+			title: "billing [~/work/billing] – …/src/Main.java – IntelliJ IDEA",
+			want: TitleInfo{
+				Parser:        "jetbrains",
+				Workspace:     "billing",
+				WorkspacePath: filepath.Join(home, "work/billing"),
+				File:          "src/Main.java",
+			},
+			ok: true,
+		},
+		{
+			name:  "neovim modified buffer",
+			app:   "Alacritty",
+			title: "tracker.go + (~/src/agent/internal) - NVIM",
+			want: TitleInfo{
+				Parser:        "vim",
+				Workspace:     "internal",
+				WorkspacePath: filepath.Join(home, "src/agent/internal"),
+				File:          filepath.Join(home, "src/agent/internal/tracker.go"),
+				Dirty:         true,
+			},
+			ok: true,
+		},
+		{
+			name:  "vim clean buffer absolute dir",
+			app:   "gvim",
+			title: "main.c (/opt/src/kernel) - GVIM1",
+			want: TitleInfo{
+				Parser:        "vim",
+				Workspace:     "kernel",
+				WorkspacePath: "/opt/src/kernel",
+				File:          "/opt/src/kernel/main.c",
+			},
+			ok: true,
+		},
+		{
+			name:  "emacs file buffer",
+			app:   "Emacs",
+			title: "/home/dev/proj/init.el - GNU Emacs at laptop",
+			want: TitleInfo{
+				Parser:        "emacs",
+				Workspace:     "proj",
+				WorkspacePath: "/home/dev/proj",
+				File:          "/home/dev/proj/init.el",
+			},
+			ok: true,
+		},
+		{
+			name:  "emacs buffer name only",
+			app:   "emacs",
+			title: "notes.org - GNU Emacs at laptop",
+			want:  TitleInfo{Parser: "emacs", File: "notes.org"},
+			ok:    true,
+		},
+		{
+			name:  "emacs scratch buffer",
+			app:   "emacs",
+			title: "*scratch* - GNU Emacs at laptop",
+			ok:    false,
+		},
+		{
+			name:  "sublime file with project",
+			app:   "sublime_text",
+			title: "~/code/shop/cart.py (shop) - Sublime Text",
+			want: TitleInfo{
+				Parser:    "sublime",
+				Workspace: "shop",
+				File:      filepath.Join(home, "code/shop/cart.py"),
+			},
+			ok: true,
+		},
+		{
+			name:  "sublime dirty marker",
+			app:   "Sublime_text",
+			title: "cart.py • (shop) - Sublime Text",
+			want:  TitleInfo{Parser: "sublime", Workspace: "shop", File: "cart.py", Dirty: true},
+			ok:    true,
+		},
+		{
+			name:  "unrecognised title",
+			app:   "firefox",
+			title: "Mozilla Firefox",
+			ok:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseTitle(tt.app, tt.title)
+			if ok != tt.ok {
+				t.Fatalf("parseTitle(%q, %q) ok = %v, want %v (got %+v)", tt.app, tt.title, ok, tt.ok, got)
+			}
+			if !tt.ok {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseTitle(%q, %q)\n got  %+v\n want %+v", tt.app, tt.title, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/liamdn8/auto-worklog-agent/internal/activitywatch"
 	"github.com/liamdn8/auto-worklog-agent/internal/config"
 	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+	"github.com/liamdn8/auto-worklog-agent/internal/privacy"
 	"github.com/liamdn8/auto-worklog-agent/internal/session"
 	"github.com/liamdn8/auto-worklog-agent/internal/watcher"
 )
@@ -25,28 +27,114 @@ const (
 
 // Tracker coordinates window activity tracking and publishes work sessions to ActivityWatch.
 type Tracker struct {
-	cfg      config.Config
-	awClient *activitywatch.Client
+	cfg         config.Config
+	awClient    *activitywatch.Client
+	policy      *privacy.Policy
+	apps        []appRule
+	subprojects *subprojectResolver
+	remoteDev   []remoteDevRule
+	identities  []identityRule
 
 	idleTimeout time.Duration
 	flushEvery  time.Duration
+	inputIdle   time.Duration
 
 	sessions map[string]*session.State
+	ended    []*session.State // ended sessions held for the merge window
 	mu       sync.Mutex
 
-	repoMu sync.RWMutex
-	repos  map[string]gitinfo.Info
+	minDuration  time.Duration
+	mergeGap     time.Duration
+	location     *time.Location
+	dayStartHour int
+
+	repoMu     sync.RWMutex
+	repos      map[string]gitinfo.Info
+	lastScan   gitinfo.DiscoveryStats
+	lastScanAt time.Time
+
+	scanMu        sync.Mutex     // serialises scans
+	index         *gitinfo.Index // guarded by scanMu
+	rescanOnStart bool
+
+	pauseMu     sync.Mutex
+	paused      bool
+	pausedUntil time.Time
+
+	pendingMu sync.Mutex
+	pending   map[string]struct{}
+
+	identityMu     sync.Mutex
+	identityWarned map[string]bool // repos warned about a missing git identity
+
+	gitWatch    *gitinfo.Watcher // nil when git state watching is unavailable
+	watchFailed map[string]bool  // repos that could not be watched, guarded by mu
+
+	gitHooks chan gitHookEvent   // notifications from installed git hooks, handled by Run
+	controls chan controlRequest // pause and flush requests, handled by Run
+
+	procCache      map[int]processRepos    // owned by the window loop goroutine
+	remoteBranches map[string]remoteBranch // owned by the window loop goroutine
 }
 
 // NewTracker builds a Tracker from configuration and client dependencies.
 func NewTracker(cfg config.Config, awClient *activitywatch.Client) (*Tracker, error) {
+	policy, err := privacy.New(cfg.Privacy)
+	if err != nil {
+		return nil, fmt.Errorf("compile privacy rules: %w", err)
+	}
+
+	apps, err := compileAppRules(cfg.Apps)
+	if err != nil {
+		return nil, fmt.Errorf("compile app rules: %w", err)
+	}
+
+	subprojects, err := newSubprojectResolver(cfg.Git.Subprojects)
+	if err != nil {
+		return nil, fmt.Errorf("compile subproject rules: %w", err)
+	}
+
+	remoteDev, err := compileRemoteDevRules(cfg.RemoteDev)
+	if err != nil {
+		return nil, fmt.Errorf("compile remoteDev rules: %w", err)
+	}
+
+	location, err := cfg.Session.Location()
+	if err != nil {
+		return nil, err
+	}
+
 	tracker := &Tracker{
-		cfg:         cfg,
-		awClient:    awClient,
-		idleTimeout: time.Duration(cfg.Session.IdleTimeoutMinutes) * time.Minute,
-		flushEvery:  cfg.Session.FlushInterval.Duration(),
-		sessions:    make(map[string]*session.State),
-		repos:       make(map[string]gitinfo.Info),
+		cfg:          cfg,
+		awClient:     awClient,
+		policy:       policy,
+		apps:         apps,
+		subprojects:  subprojects,
+		remoteDev:    remoteDev,
+		identities:   compileIdentityRules(cfg.Git.Identities),
+		idleTimeout:  time.Duration(cfg.Session.IdleTimeoutMinutes) * time.Minute,
+		flushEvery:   cfg.Session.FlushInterval.Duration(),
+		inputIdle:    cfg.Session.InputIdleThreshold.Duration(),
+		sessions:     make(map[string]*session.State),
+		minDuration:  cfg.Session.MinDuration.Duration(),
+		mergeGap:     cfg.Session.MergeGap.Duration(),
+		location:     location,
+		dayStartHour: cfg.Session.DayStartHour,
+		repos:        make(map[string]gitinfo.Info),
+		pending:      make(map[string]struct{}),
+		procCache:    make(map[int]processRepos),
+
+		remoteBranches: make(map[string]remoteBranch),
+		watchFailed:    make(map[string]bool),
+		identityWarned: make(map[string]bool),
+		gitHooks:       make(chan gitHookEvent, 64),
+		controls:       make(chan controlRequest),
+	}
+
+	if watcher, err := gitinfo.NewWatcher(); err != nil {
+		log.Printf("Git state watching unavailable, branch changes are picked up on the next poll: %v", err)
+	} else {
+		tracker.gitWatch = watcher
 	}
 
 	if tracker.flushEvery == 0 {
@@ -61,13 +149,21 @@ func NewTracker(cfg config.Config, awClient *activitywatch.Client) (*Tracker, er
 		tracker.idleTimeout = 5 * time.Minute
 	}
 
-	tracker.refreshRepositories()
+	if tracker.loadIndex() {
+		// The scan loop brings the index up to date in the background
+		tracker.rescanOnStart = true
+	} else {
+		tracker.refreshRepositories(context.Background(), true)
+	}
 
 	log.Printf(
-		"Tracker configured: repositories=%d idleTimeout=%s flushInterval=%s",
+		"Tracker configured: repositories=%d idleTimeout=%s flushInterval=%s inputIdle=%s minDuration=%s mergeGap=%s",
 		len(tracker.repos),
 		tracker.idleTimeout,
 		tracker.flushEvery,
+		tracker.inputIdle,
+		tracker.minDuration,
+		tracker.mergeGap,
 	)
 
 	if len(tracker.repos) == 0 {
@@ -89,6 +185,18 @@ func (t *Tracker) RunTest(ctx context.Context) error {
 	flushTicker := time.NewTicker(t.flushEvery)
 	defer flushTicker.Stop()
 
+	var gitChanges <-chan string
+	if t.gitWatch != nil {
+		gitChanges = t.gitWatch.Changes()
+		defer t.gitWatch.Close()
+	}
+
+	var rootChanges <-chan gitinfo.RootEvent
+	if rootWatch := t.startRootWatch(); rootWatch != nil {
+		rootChanges = rootWatch.Events()
+		defer rootWatch.Close()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -96,10 +204,19 @@ func (t *Tracker) RunTest(ctx context.Context) error {
 			return ctx.Err()
 		case evt := <-events:
 			t.recordEvent(evt)
+		case repoPath := <-gitChanges:
+			t.gitStateChanged(repoPath)
+		case evt := <-t.gitHooks:
+			t.gitHookRan(evt)
+		case req := <-t.controls:
+			t.applyControl(ctx, req)
+		case evt := <-rootChanges:
+			t.rootChanged(evt)
 		case <-flushTicker.C:
 			// Flush both expired sessions and send heartbeats for active ones
 			t.flushExpired(ctx)
 			t.flushActive(ctx)
+			t.unwatchInactive()
 		}
 	}
 }
@@ -113,11 +230,14 @@ func (t *Tracker) testActivityLoop(ctx context.Context, events chan<- repoEvent)
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if t.isPaused() {
+				continue
+			}
 			t.repoMu.RLock()
 			for _, repo := range t.repos {
 				log.Printf("TEST: Simulating activity for repo=%s", repo.Name)
 				select {
-				case events <- repoEvent{repo: repo, when: time.Now(), path: "[test-activity]", app: "test"}:
+				case events <- repoEvent{repo: repo, when: time.Now(), path: "[test-activity]", app: "test", category: defaultCategory}:
 				case <-ctx.Done():
 					t.repoMu.RUnlock()
 					return
@@ -131,12 +251,25 @@ func (t *Tracker) testActivityLoop(ctx context.Context, events chan<- repoEvent)
 // Run starts the tracker loop with embedded window watching.
 func (t *Tracker) Run(ctx context.Context) error {
 	events := make(chan repoEvent, 64)
-	go t.embeddedWindowLoop(ctx, events)
+	idleEvents := make(chan time.Time, 1)
+	go t.embeddedWindowLoop(ctx, events, idleEvents)
 	go t.repoScanLoop(ctx)
 
 	flushTicker := time.NewTicker(t.flushEvery)
 	defer flushTicker.Stop()
 
+	var gitChanges <-chan string
+	if t.gitWatch != nil {
+		gitChanges = t.gitWatch.Changes()
+		defer t.gitWatch.Close()
+	}
+
+	var rootChanges <-chan gitinfo.RootEvent
+	if rootWatch := t.startRootWatch(); rootWatch != nil {
+		rootChanges = rootWatch.Events()
+		defer rootWatch.Close()
+	}
+
 	log.Println("Embedded window watcher started - no aw-watcher-window required!")
 
 	for {
@@ -146,15 +279,26 @@ func (t *Tracker) Run(ctx context.Context) error {
 			return ctx.Err()
 		case evt := <-events:
 			t.recordEvent(evt)
+		case idleSince := <-idleEvents:
+			t.flushIdle(ctx, idleSince)
+		case repoPath := <-gitChanges:
+			t.gitStateChanged(repoPath)
+		case evt := <-t.gitHooks:
+			t.gitHookRan(evt)
+		case req := <-t.controls:
+			t.applyControl(ctx, req)
+		case evt := <-rootChanges:
+			t.rootChanged(evt)
 		case <-flushTicker.C:
 			// Flush both expired sessions and send heartbeats for active ones
 			t.flushExpired(ctx)
 			t.flushActive(ctx)
+			t.unwatchInactive()
 		}
 	}
 }
 
-func (t *Tracker) embeddedWindowLoop(ctx context.Context, events chan<- repoEvent) {
+func (t *Tracker) embeddedWindowLoop(ctx context.Context, events chan<- repoEvent, idleEvents chan<- time.Time) {
 	interval := t.cfg.Session.PollInterval.Duration()
 	if interval <= 0 {
 		interval = 1 * time.Second
@@ -163,11 +307,41 @@ func (t *Tracker) embeddedWindowLoop(ctx context.Context, events chan<- repoEven
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	userIdle := false
+	idleUnavailable := false
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if t.isPaused() {
+				continue
+			}
+
+			if t.inputIdle > 0 && !idleUnavailable {
+				idle, err := watcher.IdleTime()
+				if err != nil {
+					// Fall back to window-only activity detection
+					log.Printf("Input idle detection unavailable, relying on window activity only: %v", err)
+					idleUnavailable = true
+				} else if idle >= t.inputIdle {
+					if !userIdle {
+						userIdle = true
+						log.Printf("No input for %s, pausing session tracking", idle.Round(time.Second))
+						select {
+						case idleEvents <- time.Now().Add(-idle):
+						case <-ctx.Done():
+							return
+						}
+					}
+					continue
+				} else if userIdle {
+					userIdle = false
+					log.Printf("Input resumed after idle period")
+				}
+			}
+
 			window, err := watcher.GetActiveWindow()
 			if err != nil {
 				log.Printf("Failed to get active window: %v", err)
@@ -178,20 +352,45 @@ func (t *Tracker) embeddedWindowLoop(ctx context.Context, events chan<- repoEven
 				continue
 			}
 
-			app := strings.ToLower(window.App)
-			title := strings.ToLower(window.Title)
-
-			if !matchesKnownIDE(app, title) {
+			match, ok := matchApp(t.apps, window)
+			if !ok {
 				continue
 			}
 
-			repo, ok := t.matchCachedRepo(title)
-			if !ok {
+			// Remote workspaces never match local paths, so configured mappings go first
+			var (
+				repo       gitinfo.Info
+				found      bool
+				remoteRule remoteDevRule
+			)
+			if match.parsed.RemoteHost != "" {
+				repo, remoteRule, found = t.matchRemoteDev(match.parsed)
+			}
+			remote := found
+			if !found {
+				repo, found = t.resolveRepo(window, match)
+			}
+			if !found {
 				continue
 			}
 
+			evt := repoEvent{
+				repo:     repo,
+				when:     time.Now(),
+				path:     fmt.Sprintf("[window] %s - %s", window.App, window.Title),
+				app:      window.App,
+				title:    window.Title,
+				file:     relativeFile(repo, match.parsed.File),
+				category: match.rule.category,
+			}
+			evt.project, evt.component = t.subprojects.resolve(repo, evt.file)
+			evt.review = match.parsed.Review
+			if remote {
+				evt.branch = t.remoteBranchFor(remoteRule, match.parsed)
+			}
+
 			select {
-			case events <- repoEvent{repo: repo, when: time.Now(), path: fmt.Sprintf("[window] %s - %s", window.App, window.Title), app: window.App}:
+			case events <- evt:
 			case <-ctx.Done():
 				return
 			}
@@ -199,49 +398,105 @@ func (t *Tracker) embeddedWindowLoop(ctx context.Context, events chan<- repoEven
 	}
 }
 
-var knownIDEApps = []string{
-	"code",
-	"code-oss",
-	"visual studio code",
-	"vscode",
-	"idea",
-	"intellij",
-	"clion",
-	"goland",
-	"webstorm",
-	"phpstorm",
-	"pycharm",
-	"rider",
-	"dataspell",
-	"rubymine",
-	"vim",
-	"nvim",
-	"neovim",
-	"emacs",
-	"sublime",
+// matchCachedRepo resolves the repository for a window. Paths parsed from the
+// title win, then the parsed workspace name, then ranked matching of the raw title.
+func (t *Tracker) matchCachedRepo(title string, parsed TitleInfo) (gitinfo.Info, bool) {
+	if repo, ok := t.matchTitlePaths(parsed); ok {
+		return repo, true
+	}
+
+	t.repoMu.RLock()
+	defer t.repoMu.RUnlock()
+	return t.matchTitleIn(title, parsed, t.repos)
+}
+
+// matchTitlePaths resolves absolute file or workspace paths parsed from a title.
+func (t *Tracker) matchTitlePaths(parsed TitleInfo) (gitinfo.Info, bool) {
+	t.repoMu.RLock()
+	defer t.repoMu.RUnlock()
+
+	for _, p := range []string{parsed.File, parsed.WorkspacePath} {
+		if filepath.IsAbs(p) {
+			if repo, ok := repoContaining(p, t.repos); ok {
+				return repo, true
+			}
+		}
+	}
+	return gitinfo.Info{}, false
 }
 
-func matchesKnownIDE(app, title string) bool {
-	for _, candidate := range knownIDEApps {
-		if strings.Contains(app, candidate) || strings.Contains(title, candidate) {
-			return true
+// matchTitleIn ranks repos against the parsed workspace name, then the raw title.
+func (t *Tracker) matchTitleIn(title string, parsed TitleInfo, repos map[string]gitinfo.Info) (gitinfo.Info, bool) {
+	if parsed.Workspace != "" {
+		candidates := rankRepos(parsed.Workspace, repos)
+		if len(candidates) > 0 && candidates[0].tier >= matchToken {
+			t.logAmbiguous(parsed.Workspace, candidates)
+			return candidates[0].info, true
 		}
 	}
-	return false
+
+	candidates := rankRepos(title, repos)
+	if len(candidates) == 0 {
+		return gitinfo.Info{}, false
+	}
+	t.logAmbiguous(title, candidates)
+	return candidates[0].info, true
 }
 
-func (t *Tracker) matchCachedRepo(lowerTitle string) (gitinfo.Info, bool) {
-	lowerTitle = strings.ToLower(lowerTitle)
+// matchProcessCwd resolves the repository from the foreground job's working directory.
+func (t *Tracker) matchProcessCwd(pid int) (gitinfo.Info, bool) {
+	cwd, err := watcher.ForegroundCwd(pid)
+	if err != nil {
+		if t.cfg.Verbose {
+			log.Printf("Foreground cwd for pid %d: %v", pid, err)
+		}
+		return gitinfo.Info{}, false
+	}
+
 	t.repoMu.RLock()
 	defer t.repoMu.RUnlock()
-	for _, info := range t.repos {
-		name := strings.ToLower(info.Name)
-		path := strings.ToLower(info.Path)
-		if strings.Contains(lowerTitle, name) || strings.Contains(lowerTitle, filepath.Base(path)) || strings.Contains(lowerTitle, path) {
-			return info, true
+	return repoContaining(cwd, t.repos)
+}
+
+func (t *Tracker) logAmbiguous(title string, candidates []repoCandidate) {
+	if !t.cfg.Verbose || !ambiguous(candidates) {
+		return
+	}
+	paths := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if c.tier == candidates[0].tier && c.length == candidates[0].length {
+			paths = append(paths, c.info.Path)
 		}
 	}
-	return gitinfo.Info{}, false
+	log.Printf("Ambiguous repository match for title %q: %v; choosing %s", title, paths, candidates[0].info.Path)
+}
+
+// repoContaining returns the repository with the longest root that contains path.
+func repoContaining(path string, repos map[string]gitinfo.Info) (gitinfo.Info, bool) {
+	path = filepath.Clean(path)
+	var best gitinfo.Info
+	found := false
+	for _, info := range repos {
+		if path != info.Path && !strings.HasPrefix(path, info.Path+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(info.Path) > len(best.Path) {
+			best = info
+			found = true
+		}
+	}
+	return best, found
+}
+
+// relativeFile expresses a parsed file path relative to the repository root when possible.
+func relativeFile(repo gitinfo.Info, file string) string {
+	if file == "" || !filepath.IsAbs(file) {
+		return file
+	}
+	if rel, err := filepath.Rel(repo.Path, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
 }
 
 func (t *Tracker) repoScanLoop(ctx context.Context) {
@@ -253,110 +508,145 @@ func (t *Tracker) repoScanLoop(ctx context.Context) {
 	ticker := time.NewTicker(rescanInterval)
 	defer ticker.Stop()
 
+	if t.rescanOnStart {
+		t.refreshRepositories(ctx, false)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			t.refreshRepositories()
+			t.refreshRepositories(ctx, false)
 		}
 	}
 }
 
-func (t *Tracker) refreshRepositories() {
-	newRepos := make(map[string]gitinfo.Info)
+// discoveryOptions builds repository discovery options from configuration.
+func (t *Tracker) discoveryOptions() gitinfo.DiscoveryOptions {
+	skipFilesystems := t.cfg.Git.SkipFilesystems
+	if skipFilesystems == nil {
+		skipFilesystems = gitinfo.DefaultSkipFilesystems
+	}
+	return gitinfo.DiscoveryOptions{
+		Roots:        t.cfg.Git.Roots,
+		Repositories: t.cfg.Git.Repositories,
+		MaxDepth:     t.cfg.Git.MaxDepth,
+		Ignore:       t.cfg.Git.Ignore,
+		MaxDuration:  t.cfg.Git.ScanTimeout.Duration(),
+		MaxEntries:   t.cfg.Git.ScanMaxEntries,
+
+		OneFilesystem:   t.cfg.Git.OneFilesystem,
+		SkipFilesystems: skipFilesystems,
+		DefaultIgnore:   t.cfg.Git.IncludeDefaultIgnore,
+	}
+}
 
-	for _, repoPath := range t.cfg.Git.Repositories {
-		repo, err := gitinfo.Discover(repoPath)
-		if err != nil {
-			log.Printf("skip configured repository %s: %v", repoPath, err)
-			continue
+// loadIndex seeds the repository list from the persisted index so a restart
+// doesn't wait for a walk. It reports whether the index could be used.
+func (t *Tracker) loadIndex() bool {
+	idx, err := gitinfo.LoadIndex(t.cfg.Git.IndexPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Repository index unusable, doing a full scan: %v", err)
 		}
-		newRepos[repo.Path] = repo
+		return false
+	}
+	if !idx.Matches(t.discoveryOptions()) {
+		log.Printf("Repository index was built with different git settings, doing a full scan")
+		return false
+	}
+
+	repos := make(map[string]gitinfo.Info, len(idx.Repos))
+	for _, repo := range idx.Infos() {
+		repos[repo.Path] = repo
 	}
 
-	t.scanRoots(newRepos)
+	t.scanMu.Lock()
+	t.index = idx
+	t.scanMu.Unlock()
 
 	t.repoMu.Lock()
-	t.repos = newRepos
+	t.repos = repos
 	t.repoMu.Unlock()
 
-	log.Printf("Repository scan complete: indexed %d repositories", len(newRepos))
+	log.Printf("Loaded %d repositories from index %s (updated %s)", len(repos), t.cfg.Git.IndexPath, idx.UpdatedAt.Format(time.RFC3339))
+	return true
 }
 
-func (t *Tracker) scanRoots(dest map[string]gitinfo.Info) {
-	maxDepth := t.cfg.Git.MaxDepth
-	for _, root := range t.cfg.Git.Roots {
-		t.scanRoot(root, maxDepth, dest)
-	}
-}
+// refreshRepositories rescans the configured roots, reusing the index for
+// directories that haven't changed unless full is set.
+func (t *Tracker) refreshRepositories(ctx context.Context, full bool) gitinfo.DiscoveryStats {
+	t.scanMu.Lock()
+	defer t.scanMu.Unlock()
 
-type scanEntry struct {
-	path  string
-	depth int
-}
-
-func (t *Tracker) scanRoot(root string, maxDepth int, dest map[string]gitinfo.Info) {
-	if root == "" {
-		return
+	opts := t.discoveryOptions()
+	if !full {
+		opts.Previous = t.index
 	}
+	found, idx, stats := gitinfo.DiscoverRepositories(ctx, opts)
 
-	root = filepath.Clean(root)
-	info, err := os.Stat(root)
-	if err != nil {
-		log.Printf("repository scan: skip root %s: %v", root, err)
-		return
-	}
-	if !info.IsDir() {
-		root = filepath.Dir(root)
+	newRepos := make(map[string]gitinfo.Info, len(found))
+	for _, repo := range found {
+		newRepos[repo.Path] = repo
 	}
 
-	queue := []scanEntry{{path: root, depth: 0}}
-
-	for len(queue) > 0 {
-		entry := queue[0]
-		queue = queue[1:]
-
-		if _, seen := dest[entry.path]; seen {
-			continue
-		}
-
-		if _, err := os.Stat(filepath.Join(entry.path, ".git")); err == nil {
-			if repo, err := gitinfo.Discover(entry.path); err == nil {
-				dest[repo.Path] = repo
-			}
-			continue
-		}
-
-		if maxDepth > 0 && entry.depth >= maxDepth {
-			continue
-		}
-
-		children, err := os.ReadDir(entry.path)
-		if err != nil {
-			continue
-		}
-
-		for _, child := range children {
-			if !child.IsDir() {
-				continue
-			}
-			if child.Name() == ".git" {
+	t.repoMu.Lock()
+	if stats.Truncated {
+		// Keep repositories the partial scan didn't reach, as long as they still exist
+		for path, repo := range t.repos {
+			if _, ok := newRepos[path]; ok {
 				continue
 			}
-			if child.Type()&os.ModeSymlink != 0 {
-				continue
+			if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+				newRepos[path] = repo
 			}
-			childPath := filepath.Join(entry.path, child.Name())
-			queue = append(queue, scanEntry{path: childPath, depth: entry.depth + 1})
 		}
 	}
+	t.repos = newRepos
+	t.lastScan = stats
+	t.lastScanAt = time.Now()
+	t.repoMu.Unlock()
+
+	t.index = idx
+	if err := gitinfo.SaveIndex(t.cfg.Git.IndexPath, idx); err != nil {
+		log.Printf("Save repository index: %v", err)
+	}
+
+	kind := "Incremental"
+	if opts.Previous == nil {
+		kind = "Full"
+	}
+	log.Printf("%s repository scan complete: indexed %d repositories (found=%d dirs=%d cached=%d ignored=%d errors=%d duration=%s truncated=%t)",
+		kind, len(newRepos), stats.Repositories, stats.Directories, stats.Cached, stats.Ignored, stats.Errors, stats.Duration.Round(time.Millisecond), stats.Truncated)
+	if stats.Truncated {
+		log.Printf("Repository scan stopped early at its budget; raise git.scanTimeout or git.scanMaxEntries, or add git.ignore patterns")
+	}
+	return stats
+}
+
+// Reindex discards the repository index and walks every root again.
+func (t *Tracker) Reindex(ctx context.Context) gitinfo.DiscoveryStats {
+	log.Printf("Full repository reindex requested via control socket")
+	return t.refreshRepositories(ctx, true)
 }
 
 func (t *Tracker) recordEvent(evt repoEvent) {
-	branch, err := gitinfo.CurrentBranch(evt.repo.Path)
-	if err != nil {
-		log.Printf("resolve branch for %s: %v", evt.repo.Path, err)
+	// Drop events that were queued before a pause took effect
+	if t.isPaused() {
+		return
+	}
+
+	branch := evt.branch
+	if branch == "" && evt.repo.Kind != gitinfo.KindRemote {
+		// A rebase or bisect keeps the branch it detached HEAD from, so the
+		// session carries on through it
+		state, err := gitinfo.GetHeadState(evt.repo.Path)
+		if err != nil {
+			log.Printf("resolve branch for %s: %v", evt.repo.Path, err)
+		}
+		branch, evt.operation = state.Branch, state.Operation
+		evt.headState = err == nil
 	}
 
 	t.mu.Lock()
@@ -364,89 +654,181 @@ func (t *Tracker) recordEvent(evt repoEvent) {
 
 	repoKey := evt.repo.Path
 	sess, ok := t.sessions[repoKey]
-	if !ok {
-		// Start new session
-		sess = session.NewState(evt.repo, branch, evt.when, evt.app)
 
-		// Capture starting commit hash
-		if startHash, err := gitinfo.GetCurrentCommitHash(evt.repo.Path); err == nil {
-			sess.StartCommit = startHash
+	subject := privacy.Subject{Path: evt.repo.Path, Remote: evt.repo.Remote, Branch: branch, App: evt.app, Title: evt.title}
+	if t.policy.Excluded(subject) {
+		// Close out a session on a different branch; never start or extend an excluded one
+		if ok && branch != "" && branch != sess.Branch {
+			t.endSessionLocked(repoKey, sess)
 		}
+		return
+	}
 
-		t.sessions[repoKey] = sess
-		startCommitShort := ""
-		if len(sess.StartCommit) >= 8 {
-			startCommitShort = sess.StartCommit[:8]
+	t.watchRepoLocked(evt.repo)
+
+	if !ok {
+		// Continue a recently ended session of the same branch instead of fragmenting it
+		if sess = t.resumeEndedLocked(repoKey, branch, evt); sess != nil {
+			sess.Touch(branch, evt.app, evt.when)
+			applyEventDetails(sess, evt)
+			t.sessions[repoKey] = sess
+			log.Printf("Session resumed repo=%s branch=%s source=%s app=%s", sess.Repo.Name, sess.Branch, evt.path, sess.App)
+			return
 		}
-		log.Printf("Session started repo=%s branch=%s commit=%s source=%s app=%s", sess.Repo.Name, sess.Branch, startCommitShort, evt.path, sess.App)
+
+		t.startSessionLocked(repoKey, branch, evt)
 		return
 	}
 
+	// Split sessions at the reporting-day boundary so each event belongs to one day
+	for t.crossesDay(sess.Start, evt.when) {
+		boundary := t.nextDayBoundary(sess.Start)
+		log.Printf("Day boundary %s reached, splitting session repo=%s branch=%s",
+			boundary.Format(time.RFC3339), sess.Repo.Name, sess.Branch)
+
+		next := sess.SplitAt(boundary)
+		t.endSessionLocked(repoKey, sess)
+		sess = next
+		t.sessions[repoKey] = sess
+	}
+
 	// Check if branch has changed - if so, flush old session and start new one
 	if branch != "" && branch != sess.Branch {
 		log.Printf("Branch changed from %s to %s, flushing session repo=%s duration=%s events=%d",
 			sess.Branch, branch, sess.Repo.Name, sess.Duration(), sess.Events)
 
-		// Publish the old session
-		if err := t.publishSession(context.Background(), sess); err != nil {
-			log.Printf("publish session on branch change %s: %v", sess.Repo.Path, err)
-		}
+		// End the old session; it is published once the merge window passes
+		t.endSessionLocked(repoKey, sess)
 
-		// Start fresh session for new branch
-		sess = session.NewState(evt.repo, branch, evt.when, evt.app)
-		if startHash, err := gitinfo.GetCurrentCommitHash(evt.repo.Path); err == nil {
-			sess.StartCommit = startHash
+		// Resume a recent session on the new branch, or start fresh
+		if resumed := t.resumeEndedLocked(repoKey, branch, evt); resumed != nil {
+			resumed.Touch(branch, evt.app, evt.when)
+			applyEventDetails(resumed, evt)
+			t.sessions[repoKey] = resumed
+			log.Printf("Session resumed repo=%s branch=%s source=%s app=%s", resumed.Repo.Name, resumed.Branch, evt.path, resumed.App)
+			return
 		}
-		t.sessions[repoKey] = sess
-		startCommitShort := ""
-		if len(sess.StartCommit) >= 8 {
-			startCommitShort = sess.StartCommit[:8]
+
+		t.startSessionLocked(repoKey, branch, evt)
+		return
+	}
+
+	// Moving to another sub-project, activity category or reviewed pull
+	// request ends the session like a branch switch
+	if change := contextChange(sess, evt); change != "" {
+		log.Printf("%s, flushing session repo=%s duration=%s events=%d",
+			change, sess.Repo.Name, sess.Duration(), sess.Events)
+
+		t.endSessionLocked(repoKey, sess)
+		if resumed := t.resumeEndedLocked(repoKey, branch, evt); resumed != nil {
+			resumed.Touch(branch, evt.app, evt.when)
+			applyEventDetails(resumed, evt)
+			t.sessions[repoKey] = resumed
+			log.Printf("Session resumed repo=%s branch=%s project=%s source=%s app=%s", resumed.Repo.Name, resumed.Branch, resumed.Project, evt.path, resumed.App)
+			return
 		}
-		log.Printf("Session started repo=%s branch=%s commit=%s source=%s app=%s", sess.Repo.Name, sess.Branch, startCommitShort, evt.path, sess.App)
+
+		t.startSessionLocked(repoKey, branch, evt)
 		return
 	}
 
 	sess.Touch(branch, evt.app, evt.when)
+	applyEventDetails(sess, evt)
 
-	// Update commits - get all commits since session start
-	if sess.StartCommit != "" {
-		commits, err := gitinfo.GetCommitsSince(evt.repo.Path, sess.StartCommit)
-		if err == nil && len(commits) > 0 {
-			sess.Commits = commits
-			log.Printf("Activity detected repo=%s branch=%s commits=%d totalEvents=%d source=%s",
-				sess.Repo.Name, sess.Branch, len(sess.Commits), sess.Events, evt.path)
-		} else {
-			log.Printf("Activity detected repo=%s branch=%s source=%s totalEvents=%d",
-				sess.Repo.Name, sess.Branch, evt.path, sess.Events)
-		}
-	} else {
-		log.Printf("Activity detected repo=%s branch=%s source=%s totalEvents=%d",
-			sess.Repo.Name, sess.Branch, evt.path, sess.Events)
+	// Watched repos capture commits as they are created
+	if !t.watchingRepo(evt.repo.Path) {
+		t.refreshCommitsLocked(sess)
 	}
+	log.Printf("Activity detected repo=%s branch=%s commits=%d totalEvents=%d source=%s",
+		sess.Repo.Name, sess.Branch, len(sess.Commits), sess.Events, evt.path)
+}
+
+// startSessionLocked opens a new session for the event. The caller must hold t.mu.
+func (t *Tracker) startSessionLocked(repoKey, branch string, evt repoEvent) {
+	sess := session.NewState(evt.repo, branch, evt.when, evt.app)
+	applyEventDetails(sess, evt)
+
+	// Capture starting commit hash
+	if startHash, err := gitinfo.GetCurrentCommitHash(evt.repo.Path); err == nil {
+		sess.StartCommit = startHash
+	}
+
+	t.sessions[repoKey] = sess
+	startCommitShort := ""
+	if len(sess.StartCommit) >= 8 {
+		startCommitShort = sess.StartCommit[:8]
+	}
+	log.Printf("Session started repo=%s branch=%s commit=%s source=%s app=%s", sess.Repo.Name, sess.Branch, startCommitShort, evt.path, sess.App)
+}
+
+// contextChange describes how an event's context differs from its session's,
+// or returns "" when the event continues the session. Unknown values never
+// count as a change.
+func contextChange(sess *session.State, evt repoEvent) string {
+	switch {
+	case evt.category != "" && sess.Category != "" && evt.category != sess.Category:
+		return fmt.Sprintf("Category changed from %s to %s", sess.Category, evt.category)
+	case evt.review != "" && sess.Review != "" && evt.review != sess.Review:
+		return fmt.Sprintf("Review changed from %q to %q", sess.Review, evt.review)
+	case evt.project != "" && sess.Project != "" && evt.project != sess.Project:
+		return fmt.Sprintf("Project changed from %s to %s", sess.Project, evt.project)
+	}
+	return ""
+}
+
+// applyEventDetails copies window-derived details of an event onto its session.
+func applyEventDetails(sess *session.State, evt repoEvent) {
+	if evt.title != "" {
+		sess.Title = evt.title
+	}
+	if evt.category != "" {
+		sess.Category = evt.category
+	}
+	if evt.project != "" {
+		sess.Project = evt.project
+	}
+	if evt.component != "" {
+		sess.Component = evt.component
+	}
+	if evt.review != "" {
+		sess.Review = evt.review
+	}
+	if evt.headState {
+		sess.RecordOperation(evt.operation)
+	}
+	sess.RecordFile(evt.file)
 }
 
 func (t *Tracker) flushExpired(ctx context.Context) {
 	t.mu.Lock()
-	sessionsCopy := make([]*session.State, 0, len(t.sessions))
-	keys := make([]string, 0, len(t.sessions))
 	for key, sess := range t.sessions {
 		if time.Since(sess.LastActivity) >= t.idleTimeout {
-			sessionsCopy = append(sessionsCopy, sess)
-			keys = append(keys, key)
+			log.Printf("Ending idle session repo=%s duration=%s events=%d", sess.Repo.Name, sess.Duration(), sess.Events)
+			t.endSessionLocked(key, sess)
 		}
 	}
 	t.mu.Unlock()
 
-	for i, sess := range sessionsCopy {
-		log.Printf("Flushing idle session repo=%s duration=%s events=%d", sess.Repo.Name, sess.Duration(), sess.Events)
-		if err := t.publishSession(ctx, sess); err != nil {
-			log.Printf("publish session %s: %v", sess.Repo.Path, err)
-			continue
+	t.flushEnded(ctx, false)
+}
+
+// flushIdle ends all sessions once input idle detection reports the user away.
+// Sessions are truncated to the instant input stopped so idle time is not credited.
+func (t *Tracker) flushIdle(ctx context.Context, idleSince time.Time) {
+	t.mu.Lock()
+	for key, sess := range t.sessions {
+		if sess.LastActivity.After(idleSince) {
+			sess.LastActivity = idleSince
+			if sess.LastActivity.Before(sess.Start) {
+				sess.LastActivity = sess.Start
+			}
 		}
-		t.mu.Lock()
-		delete(t.sessions, keys[i])
-		t.mu.Unlock()
+		log.Printf("Ending session on input idle repo=%s duration=%s events=%d", sess.Repo.Name, sess.Duration(), sess.Events)
+		t.endSessionLocked(key, sess)
 	}
+	t.mu.Unlock()
+
+	t.flushEnded(ctx, false)
 }
 
 // flushActive publishes heartbeat updates for all active sessions without ending them
@@ -470,34 +852,27 @@ func (t *Tracker) flushActive(ctx context.Context) {
 	}
 }
 
+// flushAll ends every session and publishes it immediately, skipping the merge window.
 func (t *Tracker) flushAll(ctx context.Context) {
 	t.mu.Lock()
-	sessionsCopy := make([]*session.State, 0, len(t.sessions))
-	for _, sess := range t.sessions {
-		sessionsCopy = append(sessionsCopy, sess)
+	for key, sess := range t.sessions {
+		log.Printf("Flushing remaining session repo=%s duration=%s events=%d", sess.Repo.Name, sess.Duration(), sess.Events)
+		t.endSessionLocked(key, sess)
 	}
-	t.sessions = make(map[string]*session.State)
 	t.mu.Unlock()
 
-	for _, sess := range sessionsCopy {
-		log.Printf("Flushing remaining session repo=%s duration=%s events=%d", sess.Repo.Name, sess.Duration(), sess.Events)
-		if err := t.publishSession(ctx, sess); err != nil {
-			log.Printf("publish session %s: %v", sess.Repo.Path, err)
-		}
-	}
+	t.flushEnded(ctx, true)
 }
 
 func (t *Tracker) publishSession(ctx context.Context, sess *session.State) error {
-	if sess.Duration() <= 0 {
+	if sess.Duration() <= 0 || sess.Duration() < t.minDuration {
 		return nil
 	}
 
-	// Bucket name format: user_repoName_branch
-	bucketID := bucketIDForSession(sess.Repo.User, sess.Repo.Name, sess.Branch)
-
+	user, email := t.identityFor(sess.Repo)
 	data := map[string]any{
-		"gitUser":    sess.Repo.User,
-		"gitEmail":   sess.Repo.Email,
+		"gitUser":    user,
+		"gitEmail":   email,
 		"repoName":   sess.Repo.Name,
 		"repoPath":   sess.Repo.Path,
 		"branch":     sess.Branch,
@@ -505,17 +880,75 @@ func (t *Tracker) publishSession(ctx context.Context, sess *session.State) error
 		"eventCount": sess.Events,
 	}
 
+	if sess.Repo.ID != "" {
+		data["repoId"] = sess.Repo.ID
+	}
+	if len(sess.Repo.Remotes) > 0 {
+		data["remotes"] = sess.Repo.Remotes
+	}
+	if sess.Repo.Kind != gitinfo.KindRemote {
+		addUpstream(sess, data)
+	}
+
+	// Worktrees and submodules carry the identity of the repository they belong to
+	if sess.Repo.Kind == gitinfo.KindWorktree || sess.Repo.Kind == gitinfo.KindSubmodule {
+		data["repoKind"] = sess.Repo.Kind
+		data["mainRepoPath"] = sess.Repo.MainPath
+	}
+	if sess.Repo.Kind == gitinfo.KindRemote {
+		data["repoKind"] = sess.Repo.Kind
+	}
+
 	// Include application/IDE name when available
 	if sess.App != "" {
 		data["app"] = sess.App
 	}
 
+	if sess.Category != "" {
+		data["category"] = sess.Category
+	}
+
+	// Monorepo sub-project attribution
+	if sess.Project != "" {
+		data["project"] = sess.Project
+	}
+	if sess.Component != "" {
+		data["component"] = sess.Component
+	}
+
+	if sess.Review != "" {
+		data["pullRequest"] = sess.Review
+	}
+
+	if len(sess.Operations) > 0 {
+		data["operations"] = sess.Operations
+	}
+
+	if sess.Title != "" && t.cfg.Privacy.PublishTitles {
+		data["title"] = sess.Title
+	}
+
+	// Per-file event counts parsed from IDE titles
+	if len(sess.Files) > 0 {
+		data["files"] = sess.Files
+	}
+
 	// Add commits if any were made during this session
 	if len(sess.Commits) > 0 {
 		data["commits"] = sess.Commits
 		log.Printf("Publishing session with %d commits", len(sess.Commits))
 	}
 
+	t.policy.Redact(privacy.Subject{
+		Path:   sess.Repo.Path,
+		Remote: sess.Repo.Remote,
+		Branch: sess.Branch,
+		App:    sess.App,
+		Title:  sess.Title,
+	}, data)
+
+	bucketID := bucketIDForData(data, user == "")
+
 	event := activitywatch.Event{
 		Timestamp: sess.Start,
 		End:       sess.LastActivity,
@@ -524,8 +957,10 @@ func (t *Tracker) publishSession(ctx context.Context, sess *session.State) error
 	}
 
 	if err := t.awClient.RecordEvent(ctx, bucketID, bucketTypeWorkSession, event); err != nil {
+		t.markPending(sess.Repo.Path, true)
 		return fmt.Errorf("record event: %w", err)
 	}
+	t.markPending(sess.Repo.Path, false)
 
 	log.Printf("Session published repo=%s branch=%s duration=%s events=%d commits=%d bucket=%s",
 		sess.Repo.Name, sess.Branch, sess.Duration(), sess.Events, len(sess.Commits), bucketID)
@@ -533,9 +968,55 @@ func (t *Tracker) publishSession(ctx context.Context, sess *session.State) error
 	return nil
 }
 
+// addUpstream publishes the session branch's upstream with its unpushed
+// (ahead) and unmerged (behind) commit counts, and the project the work
+// flows into: the "upstream" remote of a fork, else the remote the branch
+// tracks, else the repository itself.
+func addUpstream(sess *session.State, data map[string]any) {
+	tracking, err := gitinfo.GetTracking(sess.Repo.Path, sess.Branch)
+	if err != nil {
+		log.Printf("resolve upstream for %s: %v", sess.Repo.Path, err)
+	}
+	if tracking.Upstream != "" {
+		data["upstream"] = tracking.Upstream
+	}
+	if tracking.Counted {
+		data["ahead"] = tracking.Ahead
+		data["behind"] = tracking.Behind
+	}
+
+	upstreamID := gitinfo.RemoteID(sess.Repo.Remotes["upstream"])
+	if upstreamID == "" && tracking.Remote != "" {
+		upstreamID = gitinfo.RemoteID(sess.Repo.Remotes[tracking.Remote])
+	}
+	if upstreamID == "" {
+		upstreamID = sess.Repo.ID
+	}
+	if upstreamID != "" {
+		data["upstreamRepoId"] = upstreamID
+	}
+}
+
+// bucketIDForData names the bucket of a published session, user_repo_branch,
+// from its already redacted data. The repository ID keeps clones of one
+// project together and apart from unrelated directories with the same name,
+// unless the name was hidden. Without a user name the email still tells
+// users apart.
+func bucketIDForData(data map[string]any, noUser bool) string {
+	repoKey := data["repoName"]
+	if id, ok := data["repoId"]; ok && id != privacy.RedactedValue && repoKey != privacy.RedactedValue {
+		repoKey = id
+	}
+	bucketUser := data["gitUser"]
+	if noUser {
+		bucketUser = data["gitEmail"]
+	}
+	return bucketIDForSession(fmt.Sprint(bucketUser), fmt.Sprint(repoKey), fmt.Sprint(data["branch"]))
+}
+
 var bucketSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
 
-func bucketIDForSession(user, repoName, branch string) string {
+func bucketIDForSession(user, repo, branch string) string {
 	// Format: user_repo_branch (using underscores to avoid URL parsing issues)
 	// ActivityWatch treats dots as URL path separators, which can cause issues
 	user = bucketSanitizer.ReplaceAllString(strings.ToLower(user), "-")
@@ -544,10 +1025,10 @@ func bucketIDForSession(user, repoName, branch string) string {
 		user = "unknown"
 	}
 
-	repoName = bucketSanitizer.ReplaceAllString(strings.ToLower(repoName), "-")
-	repoName = strings.Trim(repoName, "-")
-	if repoName == "" {
-		repoName = "unknown"
+	repo = bucketSanitizer.ReplaceAllString(strings.ToLower(repo), "-")
+	repo = strings.Trim(repo, "-")
+	if repo == "" {
+		repo = "unknown"
 	}
 
 	branch = bucketSanitizer.ReplaceAllString(strings.ToLower(branch), "-")
@@ -556,12 +1037,22 @@ func bucketIDForSession(user, repoName, branch string) string {
 		branch = "unknown"
 	}
 
-	return fmt.Sprintf("%s_%s_%s", user, repoName, branch)
+	return fmt.Sprintf("%s_%s_%s", user, repo, branch)
 }
 
 type repoEvent struct {
-	repo gitinfo.Info
-	when time.Time
-	path string
-	app  string
+	repo  gitinfo.Info
+	when  time.Time
+	path  string
+	app   string
+	title string
+	file  string
+
+	category  string
+	project   string
+	component string
+	review    string
+	branch    string // reported by a remote workspace's branch command
+	operation string // git operation in progress, see gitinfo.HeadState
+	headState bool   // operation was read from the working tree, so "" means none
 }
@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/activitywatch"
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
+	"github.com/liamdn8/auto-worklog-agent/internal/privacy"
+	"github.com/liamdn8/auto-worklog-agent/internal/session"
+)
+
+func TestBucketIDForRedactedData(t *testing.T) {
+	tests := []struct {
+		name   string
+		redact []string
+		noUser bool
+		want   string
+	}{
+		{name: "repo id keys the bucket", want: "me_github-com-acme-api_main"},
+		{name: "hidden name hides the id too", redact: []string{"repoName"}, want: "me_redacted_main"},
+		{name: "hidden remote falls back to the name", redact: []string{"remote"}, want: "me_api_main"},
+		{name: "hidden branch", redact: []string{"branch"}, want: "me_github-com-acme-api_redacted"},
+		{name: "email without a user name", noUser: true, want: "me-example-com_github-com-acme-api_main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := privacy.New(config.PrivacyConfig{Rules: []config.PrivacyRule{
+				{Paths: []string{"/src/**"}, Redact: append([]string{"title"}, tt.redact...)},
+			}})
+			if err != nil {
+				t.Fatalf("privacy.New: %v", err)
+			}
+			data := map[string]any{
+				"gitUser":  "Me",
+				"gitEmail": "me@example.com",
+				"repoName": "api",
+				"repoId":   "github.com/acme/api",
+				"remote":   "git@github.com:acme/api.git",
+				"branch":   "main",
+			}
+			if tt.noUser {
+				data["gitUser"] = ""
+			}
+			policy.Redact(privacy.Subject{Path: "/src/api"}, data)
+
+			if got := bucketIDForData(data, tt.noUser); got != tt.want {
+				t.Errorf("bucketIDForData = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// awRecorder is a fake ActivityWatch server that keeps the events posted to it.
+type awRecorder struct {
+	mu     sync.Mutex
+	events []activitywatch.Event
+}
+
+func (r *awRecorder) published() []activitywatch.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]activitywatch.Event(nil), r.events...)
+}
+
+// newTestTracker returns a tracker without git watching that publishes to a
+// fake ActivityWatch server.
+func newTestTracker(t *testing.T) (*Tracker, *awRecorder) {
+	t.Helper()
+	recorder := &awRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/events") {
+			var payload struct {
+				Timestamp time.Time      `json:"timestamp"`
+				Duration  float64        `json:"duration"`
+				Data      map[string]any `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			recorder.mu.Lock()
+			recorder.events = append(recorder.events, activitywatch.Event{
+				Timestamp: payload.Timestamp,
+				Duration:  time.Duration(payload.Duration * float64(time.Second)),
+				Data:      payload.Data,
+			})
+			recorder.mu.Unlock()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tracker := &Tracker{
+		awClient:       activitywatch.NewClient(config.ActivityWatchConfig{BaseURL: server.URL, Machine: "test"}),
+		sessions:       make(map[string]*session.State),
+		minDuration:    30 * time.Second,
+		mergeGap:       2 * time.Minute,
+		location:       time.UTC,
+		repos:          make(map[string]gitinfo.Info),
+		pending:        make(map[string]struct{}),
+		identityWarned: make(map[string]bool),
+		watchFailed:    make(map[string]bool),
+		procCache:      make(map[int]processRepos),
+	}
+	return tracker, recorder
+}
+
+// testEvent is window activity on a repository that doesn't exist on disk,
+// on an explicit branch so no git state is read.
+func testEvent(when time.Time, branch string) repoEvent {
+	return repoEvent{
+		repo:     gitinfo.Info{Name: "api", Path: "/nonexistent/api", User: "Me", Email: "me@example.com"},
+		when:     when,
+		path:     "test",
+		category: defaultCategory,
+		branch:   branch,
+	}
+}
+
+func TestSessionOperationClearsWhenFinished(t *testing.T) {
+	repo := t.TempDir()
+	gitDir := filepath.Join(repo, ".git")
+	if err := os.MkdirAll(filepath.Join(gitDir, "rebase-merge"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("1111111111111111111111111111111111111111\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "rebase-merge", "head-name"), []byte("refs/heads/main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker, _ := newTestTracker(t)
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	event := func(when time.Time) repoEvent {
+		evt := testEvent(when, "")
+		evt.repo.Path = repo
+		return evt
+	}
+
+	tracker.recordEvent(event(start))
+	sess := tracker.sessions[repo]
+	if sess == nil || sess.Branch != "main" || sess.Operation != gitinfo.OperationRebase {
+		t.Fatalf("session during rebase = %+v, want branch main operation rebase", sess)
+	}
+
+	// Finishing the rebase removes its state and puts HEAD back on the branch
+	if err := os.RemoveAll(filepath.Join(gitDir, "rebase-merge")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker.recordEvent(event(start.Add(time.Minute)))
+	if got := tracker.sessions[repo]; got != sess {
+		t.Fatalf("finishing the rebase started a new session")
+	}
+	if sess.Operation != "" {
+		t.Errorf("Operation = %q after the rebase finished, want none", sess.Operation)
+	}
+	if !slices.Equal(sess.Operations, []string{gitinfo.OperationRebase}) {
+		t.Errorf("Operations = %q, want [rebase]", sess.Operations)
+	}
+}
@@ -15,6 +15,11 @@ type Config struct {
 	ActivityWatch ActivityWatchConfig `json:"activityWatch"`
 	Git           GitConfig           `json:"git"`
 	Session       SessionConfig       `json:"session"`
+	Control       ControlConfig       `json:"control"`
+	Privacy       PrivacyConfig       `json:"privacy"`
+	Apps          AppsConfig          `json:"apps"`
+	RemoteDev     []RemoteDevRule     `json:"remoteDev"`
+	Verbose       bool                `json:"verbose"`
 }
 
 // ActivityWatchConfig holds the aw-server integration settings.
@@ -30,6 +35,52 @@ type GitConfig struct {
 	Roots             []string `json:"roots"`
 	MaxDepth          int      `json:"maxDepth"`
 	RescanIntervalMin int      `json:"rescanIntervalMin"`
+	// Ignore skips directories while scanning roots: plain names match at
+	// any depth, patterns with a slash match paths ("**" crosses directories).
+	Ignore []string `json:"ignore"`
+	// IncludeDefaultIgnore adds the built-in ignore list (node_modules, vendor, caches, ...).
+	IncludeDefaultIgnore bool `json:"includeDefaultIgnore"`
+	// OneFilesystem keeps scans from crossing into other mounts below a
+	// root. SkipFilesystems lists filesystem type globs whose mounts are
+	// never entered; unset uses the built-in list (NFS, SMB, FUSE, /proc
+	// and other pseudo filesystems) and an empty list enters every mount.
+	OneFilesystem   bool     `json:"oneFilesystem"`
+	SkipFilesystems []string `json:"skipFilesystems"`
+	// ScanTimeout and ScanMaxEntries bound one scan; zero is unlimited.
+	ScanTimeout    jsonDuration `json:"scanTimeout"`
+	ScanMaxEntries int          `json:"scanMaxEntries"`
+	// IndexPath is where discovered repositories and directory mtimes are
+	// persisted between scans and restarts.
+	IndexPath string `json:"indexPath"`
+	// WatchRoots watches the root directories so new clones and deleted
+	// repositories are picked up immediately; MaxRootWatches caps the
+	// directories watched (zero is unlimited, up to the kernel limit).
+	WatchRoots     bool `json:"watchRoots"`
+	MaxRootWatches int  `json:"maxRootWatches"`
+	// Subprojects attribute time inside monorepos to individual services.
+	Subprojects []SubprojectRule `json:"subprojects"`
+	// Identities override the git identity of repositories at or below a
+	// root; the longest matching root wins and empty fields keep the
+	// identity resolved from git config and .mailmap.
+	Identities []IdentityRule `json:"identities"`
+}
+
+// SubprojectRule maps files of a repository to a project and component.
+// The project comes from the longest matching directory prefix, or else the
+// nearest directory holding one of the manifests; the component is the first
+// owner from CODEOWNERS.
+type SubprojectRule struct {
+	Repo       string            `json:"repo"`      // repo name, path or path glob
+	Prefixes   map[string]string `json:"prefixes"`  // directory prefix -> project name
+	Manifests  []string          `json:"manifests"` // e.g. go.mod, package.json, pom.xml
+	Codeowners bool              `json:"codeowners"`
+}
+
+// IdentityRule sets the name and email published for repositories under Root.
+type IdentityRule struct {
+	Root  string `json:"root"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
 
 // SessionConfig controls session detection behavior.
@@ -38,6 +89,100 @@ type SessionConfig struct {
 	PollInterval       jsonDuration `json:"pollInterval"`
 	FlushInterval      jsonDuration `json:"flushInterval"`
 	PulseTime          jsonDuration `json:"pulseTime"`
+	// InputIdleThreshold stops accruing session time once no keyboard or mouse
+	// input has been seen for this long. Zero disables input-based idle detection.
+	InputIdleThreshold jsonDuration `json:"inputIdleThreshold"`
+	// MinDuration drops ended sessions shorter than this instead of publishing them.
+	MinDuration jsonDuration `json:"minDuration"`
+	// MergeGap joins consecutive sessions of the same repo and branch separated
+	// by less than this into one. Zero disables merging.
+	MergeGap jsonDuration `json:"mergeGap"`
+	// Timezone is the IANA zone used to split sessions at midnight; empty means local time.
+	Timezone string `json:"timezone"`
+	// DayStartHour shifts the day boundary for night owls (e.g. 4 splits at 04:00).
+	DayStartHour int `json:"dayStartHour"`
+}
+
+// ControlConfig configures the local control socket used by the CLI subcommands.
+type ControlConfig struct {
+	SocketPath string `json:"socketPath"`
+}
+
+// AppsConfig defines which windows count as development activity.
+type AppsConfig struct {
+	Rules []AppRule `json:"rules"`
+	// IncludeDefaults appends the built-in IDE rules after the configured ones.
+	IncludeDefaults bool `json:"includeDefaults"`
+	// GitLabHost is the GitLab instance the built-in merge request rule
+	// attributes to.
+	GitLabHost string `json:"gitlabHost"`
+}
+
+// AppRule recognises an application by window class, executable or command
+// line regex. An optional title regex must also match; its named groups
+// "workspace", "workspacePath", "file" and "dirty" describe what the window
+// is editing.
+type AppRule struct {
+	Name  string `json:"name"`
+	Class string `json:"class"`
+	Exe   string `json:"exe"`
+	// Cmdline matches the space-joined command line, for apps run by a
+	// generic launcher such as java, node or electron.
+	Cmdline  string `json:"cmdline"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	// Forge is the host of the repository a "remote" title group names,
+	// e.g. github.com. Without it the remote matches a clone on any host.
+	Forge string `json:"forge"`
+	// ProcessCwd attributes activity to the repo containing the working
+	// directory of the window's foreground job (for terminals).
+	ProcessCwd bool `json:"processCwd"`
+}
+
+// RemoteDevRule maps remote development workspaces (VS Code Remote-SSH, Dev
+// Containers, WSL) to a repository. The local mirror is tried first, then a
+// discovered clone with the same remote URL; with neither, activity is
+// attributed to the remote URL itself. Templates may use {host} and {workspace}.
+type RemoteDevRule struct {
+	Kind        string `json:"kind"`        // ssh, dev-container, wsl, codespaces; empty matches any
+	Host        string `json:"host"`        // host, container or distribution glob
+	LocalMirror string `json:"localMirror"` // local checkout; {workspace} is appended when absent
+	RemoteURL   string `json:"remoteURL"`   // e.g. git@github.com:acme/{workspace}.git
+	// BranchCommand prints the workspace's current branch, e.g.
+	// "ssh {host} git -C ~/src/{workspace} branch --show-current".
+	BranchCommand string `json:"branchCommand"`
+}
+
+// PrivacyConfig lists rules that exclude activity or redact published fields.
+type PrivacyConfig struct {
+	Rules []PrivacyRule `json:"rules"`
+	// PublishTitles adds the raw window title to published sessions. Titles
+	// often name clients or tickets, so they are kept local unless enabled.
+	PublishTitles bool `json:"publishTitles"`
+}
+
+// PrivacyRule matches activity when every non-empty criterion matches (any
+// pattern within a criterion may match), then excludes it or redacts fields.
+type PrivacyRule struct {
+	Paths    []string `json:"paths"`    // repository path globs, "**" crosses directories
+	Remotes  []string `json:"remotes"`  // remote URL globs, case-insensitive
+	Branches []string `json:"branches"` // branch name regexes
+	Apps     []string `json:"apps"`     // application names, case-insensitive
+	Titles   []string `json:"titles"`   // window title regexes
+	Exclude  bool     `json:"exclude"`
+	Redact   []string `json:"redact"` // e.g. repoPath, remote, gitEmail, title
+}
+
+// Location resolves the configured session timezone.
+func (s SessionConfig) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load session.timezone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
 }
 
 type jsonDuration struct {
@@ -125,12 +270,28 @@ func defaultConfig() Config {
 			Roots:             roots,
 			MaxDepth:          5,
 			RescanIntervalMin: 5,
+
+			IncludeDefaultIgnore: true,
+			ScanTimeout:          newJSONDuration(2 * time.Minute),
+			IndexPath:            defaultIndexPath(),
+			WatchRoots:           true,
+			MaxRootWatches:       8192,
 		},
 		Session: SessionConfig{
 			IdleTimeoutMinutes: 5,
 			PollInterval:       newJSONDuration(5 * time.Second),
 			FlushInterval:      newJSONDuration(15 * time.Second),
 			PulseTime:          newJSONDuration(10 * time.Second),
+			InputIdleThreshold: newJSONDuration(3 * time.Minute),
+			MinDuration:        newJSONDuration(30 * time.Second),
+			MergeGap:           newJSONDuration(2 * time.Minute),
+		},
+		Control: ControlConfig{
+			SocketPath: defaultSocketPath(),
+		},
+		Apps: AppsConfig{
+			IncludeDefaults: true,
+			GitLabHost:      "gitlab.com",
 		},
 	}
 }
@@ -143,6 +304,21 @@ func hostnameOrUnknown() string {
 	return host
 }
 
+func defaultSocketPath() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "awagent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("awagent-%d.sock", os.Getuid()))
+}
+
+func defaultIndexPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "awagent", "repos.json")
+}
+
 func expandPath(path string) (string, error) {
 	if len(path) == 0 {
 		return path, nil
@@ -187,6 +363,51 @@ func (cfg *Config) normalize() error {
 	}
 	cfg.Git.Roots = roots
 
+	for i, pattern := range cfg.Git.Ignore {
+		if strings.ContainsAny(pattern, "~$") {
+			expanded, err := expandPath(pattern)
+			if err != nil {
+				return fmt.Errorf("expand git ignore pattern: %w", err)
+			}
+			cfg.Git.Ignore[i] = expanded
+		}
+	}
+	if cfg.Git.ScanMaxEntries < 0 {
+		cfg.Git.ScanMaxEntries = 0
+	}
+	if cfg.Git.MaxRootWatches < 0 {
+		cfg.Git.MaxRootWatches = 0
+	}
+	if cfg.Git.IndexPath == "" {
+		cfg.Git.IndexPath = defaultIndexPath()
+	}
+	indexPath, err := expandPath(cfg.Git.IndexPath)
+	if err != nil {
+		return fmt.Errorf("expand git index path: %w", err)
+	}
+	cfg.Git.IndexPath = filepath.Clean(indexPath)
+
+	for i := range cfg.Git.Subprojects {
+		if strings.ContainsAny(cfg.Git.Subprojects[i].Repo, "/~$") {
+			repo, err := expandPath(cfg.Git.Subprojects[i].Repo)
+			if err != nil {
+				return fmt.Errorf("expand subproject repo: %w", err)
+			}
+			cfg.Git.Subprojects[i].Repo = filepath.Clean(repo)
+		}
+	}
+
+	for i := range cfg.Git.Identities {
+		if strings.TrimSpace(cfg.Git.Identities[i].Root) == "" {
+			return fmt.Errorf("git.identities[%d]: root is required", i)
+		}
+		root, err := expandPath(strings.TrimSpace(cfg.Git.Identities[i].Root))
+		if err != nil {
+			return fmt.Errorf("expand identity root: %w", err)
+		}
+		cfg.Git.Identities[i].Root = filepath.Clean(root)
+	}
+
 	// Zero or negative maxDepth means unlimited
 	if cfg.Git.MaxDepth < 0 {
 		cfg.Git.MaxDepth = 0
@@ -202,5 +423,39 @@ func (cfg *Config) normalize() error {
 		cfg.ActivityWatch.Machine = hostnameOrUnknown()
 	}
 
+	for i := range cfg.Privacy.Rules {
+		paths, err := expandPaths(cfg.Privacy.Rules[i].Paths)
+		if err != nil {
+			return fmt.Errorf("expand privacy rule paths: %w", err)
+		}
+		cfg.Privacy.Rules[i].Paths = paths
+	}
+
+	for i := range cfg.RemoteDev {
+		if mirror := cfg.RemoteDev[i].LocalMirror; mirror != "" {
+			expanded, err := expandPath(mirror)
+			if err != nil {
+				return fmt.Errorf("expand remoteDev local mirror: %w", err)
+			}
+			cfg.RemoteDev[i].LocalMirror = filepath.Clean(expanded)
+		}
+	}
+
+	if cfg.Session.DayStartHour < 0 || cfg.Session.DayStartHour > 23 {
+		return fmt.Errorf("session.dayStartHour must be between 0 and 23, got %d", cfg.Session.DayStartHour)
+	}
+	if _, err := cfg.Session.Location(); err != nil {
+		return err
+	}
+
+	if cfg.Control.SocketPath == "" {
+		cfg.Control.SocketPath = defaultSocketPath()
+	}
+	socketPath, err := expandPath(cfg.Control.SocketPath)
+	if err != nil {
+		return fmt.Errorf("expand control socket path: %w", err)
+	}
+	cfg.Control.SocketPath = filepath.Clean(socketPath)
+
 	return nil
 }
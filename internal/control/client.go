@@ -0,0 +1,50 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// reindexTimeout bounds how long the client waits for a full rescan.
+const reindexTimeout = 15 * time.Minute
+
+// Send delivers a request to the agent listening on the socket and returns its response.
+func Send(path string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return Response{}, fmt.Errorf("connect to agent at %s (is awagent running?): %w", path, err)
+	}
+	defer conn.Close()
+	timeout := connTimeout
+	if req.Command == CommandReindex {
+		// A full walk of large roots takes a while
+		timeout = reindexTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("marshal request: %w", err)
+	}
+	if _, err := conn.Write(append(body, '\n')); err != nil {
+		return Response{}, fmt.Errorf("send request: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return Response{}, fmt.Errorf("read response: %w", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return Response{}, fmt.Errorf("decode response: %w", err)
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,32 @@
+package control
+
+import (
+	"github.com/liamdn8/auto-worklog-agent/internal/agent"
+)
+
+// Command names understood by the control server.
+const (
+	CommandPause  = "pause"
+	CommandResume = "resume"
+	CommandFlush  = "flush"
+	CommandStatus = "status"
+	// CommandReindex discards the repository index and rescans every root.
+	CommandReindex = "reindex"
+	// CommandGitHook reports a git hook that ran in a repository.
+	CommandGitHook = "git-hook"
+)
+
+// Request is a single newline-delimited JSON message sent to the control socket.
+type Request struct {
+	Command  string `json:"command"`
+	Duration string `json:"duration,omitempty"`
+	Hook     string `json:"hook,omitempty"` // git hook name, for CommandGitHook
+	Path     string `json:"path,omitempty"` // directory the hook ran in
+}
+
+// Response is the server's reply to a Request.
+type Response struct {
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	Status *agent.Status `json:"status,omitempty"`
+}
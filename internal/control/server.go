@@ -0,0 +1,149 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/agent"
+)
+
+const connTimeout = 30 * time.Second
+
+// Server exposes tracker controls over a Unix domain socket.
+type Server struct {
+	path    string
+	tracker *agent.Tracker
+}
+
+// NewServer creates a control server bound to the given socket path.
+func NewServer(path string, tracker *agent.Tracker) *Server {
+	return &Server{
+		path:    path,
+		tracker: tracker,
+	}
+}
+
+// Serve listens on the socket until the context is cancelled.
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	defer os.Remove(s.path)
+
+	log.Printf("Control socket listening on %s", s.path)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			log.Printf("control socket accept: %v", err)
+			continue
+		}
+		go s.handle(ctx, conn)
+	}
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, fmt.Errorf("create socket dir: %w", err)
+	}
+
+	if _, err := os.Stat(s.path); err == nil {
+		// A live agent still answers on the socket; a stale file from a crash does not
+		if conn, dialErr := net.DialTimeout("unix", s.path, time.Second); dialErr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another agent is already listening on %s", s.path)
+		}
+		if err := os.Remove(s.path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", s.path, err)
+	}
+
+	if err := os.Chmod(s.path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("restrict socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(connTimeout))
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		log.Printf("control socket read: %v", err)
+		return
+	}
+
+	var req Request
+	resp := Response{OK: true}
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp = Response{Error: fmt.Sprintf("invalid request: %v", err)}
+	} else if err := s.dispatch(ctx, req, &resp); err != nil {
+		resp = Response{Error: err.Error()}
+	}
+
+	// Commands such as reindex may outlast the read deadline
+	conn.SetDeadline(time.Now().Add(connTimeout))
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Printf("control socket write: %v", err)
+	}
+}
+
+func (s *Server) dispatch(ctx context.Context, req Request, resp *Response) error {
+	switch req.Command {
+	case CommandPause:
+		var d time.Duration
+		if req.Duration != "" {
+			parsed, err := time.ParseDuration(req.Duration)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q: %w", req.Duration, err)
+			}
+			d = parsed
+		}
+		s.tracker.Pause(ctx, d)
+	case CommandResume:
+		s.tracker.Resume()
+	case CommandFlush:
+		s.tracker.Flush(ctx)
+	case CommandStatus:
+	case CommandReindex:
+		s.tracker.Reindex(ctx)
+	case CommandGitHook:
+		if err := s.tracker.GitHook(req.Hook, req.Path); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown command %q", req.Command)
+	}
+
+	status := s.tracker.Status()
+	resp.Status = &status
+	return nil
+}
@@ -0,0 +1,120 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/activitywatch"
+	"github.com/liamdn8/auto-worklog-agent/internal/agent"
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+)
+
+// startServer serves a tracker that is not running on a socket in a
+// temporary directory and returns the socket path.
+func startServer(t *testing.T) string {
+	t.Helper()
+	// Keep the default roots and repository index away from the real home
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	tracker, err := agent.NewTracker(cfg, activitywatch.NewClient(cfg.ActivityWatch))
+	if err != nil {
+		t.Fatalf("NewTracker: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewServer(path, tracker).Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+
+	// Wait for the listener
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return path
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("control socket %s never came up", path)
+		}
+	}
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	path := startServer(t)
+
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr string
+	}{
+		{name: "status", req: Request{Command: CommandStatus}},
+		{name: "resume", req: Request{Command: CommandResume}},
+		{name: "unknown command", req: Request{Command: "dance"}, wantErr: `unknown command "dance"`},
+		{name: "invalid pause duration", req: Request{Command: CommandPause, Duration: "soon"}, wantErr: `invalid duration "soon"`},
+		{name: "unknown git hook", req: Request{Command: CommandGitHook, Hook: "pre-push", Path: t.TempDir()}, wantErr: `unknown git hook "pre-push"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Send(path, tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Send error = %v, want %q", err, tt.wantErr)
+				}
+				if resp.OK || resp.Status != nil {
+					t.Errorf("failed request answered %+v", resp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if !resp.OK || resp.Status == nil {
+				t.Errorf("response = %+v, want ok with a status", resp)
+			}
+		})
+	}
+}
+
+func TestServerRejectsMalformedRequest(t *testing.T) {
+	path := startServer(t)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write([]byte("{\"command\": \"status\"\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.Fatalf("decode response %q: %v", line, err)
+	}
+	if resp.OK || !strings.HasPrefix(resp.Error, "invalid request") {
+		t.Errorf("response = %+v, want an invalid request error", resp)
+	}
+}
@@ -0,0 +1,298 @@
+package gitinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxIncludeDepth matches git's limit on nested config includes.
+const maxIncludeDepth = 10
+
+// gitConfig holds config values keyed by "section.subsection.key"; section
+// and key names are lowercased as git does, later values win.
+type gitConfig map[string][]string
+
+func (c gitConfig) get(key string) string {
+	values := c[key]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// configScope is what includeIf conditions are evaluated against.
+type configScope struct {
+	l      layout
+	branch string
+	// remoteURLs feed hasconfig:remote.*.url conditions. They are only known
+	// after a first pass, which sets deferred when it skipped such an include.
+	remoteURLs []string
+	resolved   bool
+	deferred   bool
+	// included lists the include paths the last read followed, found or not.
+	included []string
+}
+
+// readConfig loads the system, global and repository config of a working tree,
+// following include and includeIf (gitdir, onbranch and hasconfig) directives.
+func readConfig(l layout, branch string) gitConfig {
+	cfg, _ := readConfigFiles(configFiles(l), l, branch)
+	return cfg
+}
+
+// readConfigFiles also returns the included files it followed.
+func readConfigFiles(files []string, l layout, branch string) (gitConfig, []string) {
+	scope := &configScope{l: l, branch: branch}
+	cfg := scope.read(files)
+	if scope.deferred {
+		// As in git, hasconfig:remote.*.url sees the remotes defined
+		// everywhere except in the files it includes
+		for _, url := range remotesFromConfig(cfg) {
+			scope.remoteURLs = append(scope.remoteURLs, url)
+		}
+		scope.resolved = true
+		cfg = scope.read(files)
+	}
+	return cfg, scope.included
+}
+
+func (s *configScope) read(files []string) gitConfig {
+	s.included = nil
+	cfg := make(gitConfig)
+	for _, file := range files {
+		cfg.load(file, s, 0)
+	}
+	return cfg
+}
+
+// configFiles lists config files in increasing order of precedence.
+func configFiles(l layout) []string {
+	files := sharedConfigFiles()
+	files = append(files, filepath.Join(l.commonDir, "config"))
+	if l.gitDir != l.commonDir {
+		files = append(files, filepath.Join(l.gitDir, "config.worktree"))
+	}
+	return files
+}
+
+// sharedConfigFiles lists the system and global config files.
+func sharedConfigFiles() []string {
+	var files []string
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		files = append(files, "/etc/gitconfig")
+	}
+
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		files = append(files, global)
+	} else {
+		xdg := os.Getenv("XDG_CONFIG_HOME")
+		home, _ := os.UserHomeDir()
+		if xdg == "" && home != "" {
+			xdg = filepath.Join(home, ".config")
+		}
+		if xdg != "" {
+			files = append(files, filepath.Join(xdg, "git", "config"))
+		}
+		if home != "" {
+			files = append(files, filepath.Join(home, ".gitconfig"))
+		}
+	}
+	return files
+}
+
+func (c gitConfig) load(file string, scope *configScope, depth int) {
+	if depth > maxIncludeDepth {
+		return
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Continuation lines
+		for strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) && scanner.Scan() {
+			line = line[:len(line)-1] + strings.TrimSpace(scanner.Text())
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				continue
+			}
+			section = parseSectionHeader(line[1:end])
+			line = strings.TrimSpace(line[end+1:])
+			if line == "" || line[0] == '#' || line[0] == ';' {
+				continue
+			}
+		}
+		if section == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(line, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if hasValue {
+			value = parseConfigValue(value)
+		} else {
+			value = "true"
+		}
+		key := section + "." + name
+		c[key] = append(c[key], value)
+
+		if name == "path" && (section == "include" || strings.HasPrefix(section, "includeif.")) {
+			if section != "include" && !scope.includeApplies(strings.TrimPrefix(section, "includeif."), file) {
+				continue
+			}
+			path := includePath(value, file)
+			scope.included = append(scope.included, path)
+			c.load(path, scope, depth+1)
+		}
+	}
+}
+
+// parseSectionHeader turns `remote "origin"` into "remote.origin" and
+// `includeIf "gitdir:~/work/"` into "includeif.gitdir:~/work/".
+func parseSectionHeader(header string) string {
+	name, sub, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		// The deprecated [section.subsection] syntax is case-insensitive throughout
+		return strings.ToLower(name)
+	}
+	sub = strings.TrimSpace(sub)
+	sub = strings.TrimSuffix(strings.TrimPrefix(sub, `"`), `"`)
+	sub = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(sub)
+	return strings.ToLower(name) + "." + sub
+}
+
+// parseConfigValue strips comments and quotes and resolves escapes.
+func parseConfigValue(raw string) string {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(raw[i])
+			}
+		case (c == '#' || c == ';') && !quoted:
+			return strings.TrimSpace(b.String())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func includePath(path, from string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(filepath.Dir(from), path)
+	}
+	return path
+}
+
+// includeApplies evaluates an includeIf condition.
+func (s *configScope) includeApplies(condition, from string) bool {
+	switch {
+	case strings.HasPrefix(condition, "gitdir:"):
+		return s.l.gitDir != "" && matchGitDir(strings.TrimPrefix(condition, "gitdir:"), from, s.l.gitDir, false)
+	case strings.HasPrefix(condition, "gitdir/i:"):
+		return s.l.gitDir != "" && matchGitDir(strings.TrimPrefix(condition, "gitdir/i:"), from, s.l.gitDir, true)
+	case strings.HasPrefix(condition, "onbranch:"):
+		pattern := strings.TrimPrefix(condition, "onbranch:")
+		if strings.HasSuffix(pattern, "/") {
+			pattern += "**"
+		}
+		return s.branch != "" && configGlob(pattern, false).MatchString(s.branch)
+	case strings.HasPrefix(condition, "hasconfig:remote.*.url:"):
+		if !s.resolved {
+			s.deferred = true
+			return false
+		}
+		glob := configGlob(strings.TrimPrefix(condition, "hasconfig:remote.*.url:"), false)
+		for _, url := range s.remoteURLs {
+			if glob.MatchString(url) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGitDir applies git's gitdir pattern rules: "~/" and "./" are
+// expanded, relative patterns match anywhere and a trailing "/" matches
+// everything below.
+func matchGitDir(pattern, from, gitDir string, fold bool) bool {
+	switch {
+	case pattern == "~" || strings.HasPrefix(pattern, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = filepath.ToSlash(home) + pattern[1:]
+	case strings.HasPrefix(pattern, "./"):
+		pattern = filepath.ToSlash(filepath.Dir(from)) + pattern[1:]
+	case !strings.HasPrefix(pattern, "/"):
+		pattern = "**/" + pattern
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return configGlob(pattern, fold).MatchString(filepath.ToSlash(gitDir))
+}
+
+// configGlob converts a wildmatch pattern into a regexp; "**" crosses directories.
+func configGlob(pattern string, fold bool) *regexp.Regexp {
+	var b strings.Builder
+	if fold {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" also matches no directories at all
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,146 @@
+package gitinfo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFile creates a fixture file, and its directory, below t.TempDir().
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	writeFile(t, file, `# leading comment
+[user]
+	name = "Jane \"JD\" Doe" ; trailing comment
+	email = jane@example.com # another comment
+[core]
+	SSHCommand = "ssh -i ~/.ssh/id; -o IdentitiesOnly=yes"
+	excludesFile = C:\\Users\\jane\\ignore
+	bare
+	tabbed = "a\tb"
+	spaced =   padded value
+	joined = first \
+		second
+[alias] lg = log --oneline
+`)
+
+	cfg := make(gitConfig)
+	cfg.load(file, &configScope{}, 0)
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "user.name", want: `Jane "JD" Doe`},
+		{key: "user.email", want: "jane@example.com"},
+		{key: "core.sshcommand", want: "ssh -i ~/.ssh/id; -o IdentitiesOnly=yes"},
+		{key: "core.excludesfile", want: `C:\Users\jane\ignore`},
+		{key: "core.bare", want: "true"},
+		{key: "core.tabbed", want: "a\tb"},
+		{key: "core.spaced", want: "padded value"},
+		{key: "core.joined", want: "first second"},
+		{key: "alias.lg", want: "log --oneline"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.get(tt.key); got != tt.want {
+			t.Errorf("get(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseSectionHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "core", want: "core"},
+		{header: "Core", want: "core"},
+		{header: `remote "origin"`, want: "remote.origin"},
+		{header: `Remote "Origin"`, want: "remote.Origin"},
+		{header: `branch "feature/X"`, want: "branch.feature/X"},
+		{header: `includeIf "gitdir:~/Work/"`, want: "includeif.gitdir:~/Work/"},
+		{header: `section "quote \" and \\ backslash"`, want: `section.quote " and \ backslash`},
+		{header: "Branch.Main", want: "branch.main"},
+	}
+
+	for _, tt := range tests {
+		if got := parseSectionHeader(tt.header); got != tt.want {
+			t.Errorf("parseSectionHeader(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestConfigIncludes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "work", "api")
+	gitDir := filepath.Join(repo, ".git")
+	global := filepath.Join(home, ".gitconfig")
+	writeFile(t, global, `[user]
+	name = Default
+	email = default@example.com
+[include]
+	path = .config/git/aliases
+[includeIf "gitdir:~/work/"]
+	path = ~/.config/git/work
+[includeIf "gitdir:~/personal/"]
+	path = ~/.config/git/personal
+[includeIf "gitdir/i:~/WORK/API/"]
+	path = ~/.config/git/folded
+[includeIf "onbranch:release/"]
+	path = ~/.config/git/release
+`)
+	writeFile(t, filepath.Join(home, ".config", "git", "aliases"), "[alias]\n\tst = status\n")
+	writeFile(t, filepath.Join(home, ".config", "git", "work"), "[user]\n\temail = me@work.example\n\t[include]\n\tpath = team\n")
+	writeFile(t, filepath.Join(home, ".config", "git", "team"), "[user]\n\tname = Work Me\n")
+	writeFile(t, filepath.Join(home, ".config", "git", "personal"), "[user]\n\temail = me@home.example\n")
+	writeFile(t, filepath.Join(home, ".config", "git", "folded"), "[core]\n\tfolded = yes\n")
+	writeFile(t, filepath.Join(home, ".config", "git", "release"), "[user]\n\tsigningkey = REL\n")
+
+	tests := []struct {
+		branch string
+		key    string
+		want   string
+	}{
+		{branch: "main", key: "alias.st", want: "status"},
+		{branch: "main", key: "user.email", want: "me@work.example"},
+		{branch: "main", key: "user.name", want: "Work Me"},
+		{branch: "main", key: "core.folded", want: "yes"},
+		{branch: "main", key: "user.signingkey", want: ""},
+		{branch: "release/1.2", key: "user.signingkey", want: "REL"},
+	}
+
+	l := layout{kind: KindRepository, gitDir: gitDir, commonDir: gitDir}
+	for _, tt := range tests {
+		t.Run(tt.branch+"/"+tt.key, func(t *testing.T) {
+			cfg, _ := readConfigFiles([]string{global}, l, tt.branch)
+			if got := cfg.get(tt.key); got != tt.want {
+				t.Errorf("get(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	_, included := readConfigFiles([]string{global}, l, "main")
+	want := []string{
+		filepath.Join(home, ".config", "git", "aliases"),
+		filepath.Join(home, ".config", "git", "work"),
+		filepath.Join(home, ".config", "git", "team"),
+		filepath.Join(home, ".config", "git", "folded"),
+	}
+	if !slices.Equal(included, want) {
+		t.Errorf("included = %q, want %q", included, want)
+	}
+}
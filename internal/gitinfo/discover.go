@@ -0,0 +1,414 @@
+package gitinfo
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultIgnore lists directories that never hold repositories worth
+// tracking but are expensive to walk.
+var DefaultIgnore = []string{
+	"node_modules", "vendor", "target", "build", "dist", "venv", ".venv", "__pycache__",
+	".cache", ".npm", ".cargo", ".rustup", ".terraform", ".gradle", ".m2", ".idea", ".vscode",
+	".local/share", "go/pkg/mod", ".Trash",
+}
+
+// DiscoveryOptions configures a repository discovery run.
+type DiscoveryOptions struct {
+	Roots        []string // directory trees to walk
+	Repositories []string // explicit repositories, always included
+	MaxDepth     int      // directory levels below a root; 0 is unlimited
+	// Ignore skips directories by name ("node_modules") or, for patterns
+	// containing a slash, by path; relative paths match below any directory
+	// and "**" crosses directories.
+	Ignore      []string
+	Workers     int           // parallel directory readers; 0 uses the CPU count
+	MaxDuration time.Duration // stop walking after this long; 0 is unlimited
+	MaxEntries  int           // stop after reading this many directories; 0 is unlimited
+	// Previous is the index of an earlier run. Directories whose mtime is
+	// unchanged are not re-read and unchanged repositories are not
+	// re-inspected. It is ignored when built with different options.
+	Previous *Index
+	// OneFilesystem stops the walk at mount points below a root, like
+	// find -xdev; SkipFilesystems only skips mounts whose filesystem type
+	// matches one of its globs ("nfs*", "fuse.sshfs"). Linux only.
+	OneFilesystem   bool
+	SkipFilesystems []string
+
+	// DefaultIgnore also skips the DefaultIgnore names. Unlike Ignore they
+	// never hide a directory that is itself a repository, so a project
+	// named build or vendor is still found.
+	DefaultIgnore bool
+}
+
+// DiscoveryStats summarises a discovery run.
+type DiscoveryStats struct {
+	Repositories int           `json:"repositories"`
+	Directories  int           `json:"directories"` // directories read
+	Cached       int           `json:"cached"`      // directories and repositories reused from the index
+	Ignored      int           `json:"ignored"`     // directories skipped by ignore patterns, ignore files and mount rules
+	Errors       int           `json:"errors"`      // unreadable directories and broken repositories
+	Duration     time.Duration `json:"duration"`
+	// Truncated is set when the time or entry budget ran out before every
+	// root was walked completely.
+	Truncated bool `json:"truncated"`
+}
+
+// DiscoverRepositories finds the explicit repositories plus every repository
+// under the roots, walking directories in parallel. Worktrees and submodules
+// (".git" files) are included. Results are sorted by path, and the returned
+// index can be saved and passed back as Previous for an incremental rescan.
+func DiscoverRepositories(ctx context.Context, opts DiscoveryOptions) ([]Info, *Index, DiscoveryStats) {
+	start := time.Now()
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	d := &discovery{
+		ctx:        ctx,
+		maxDepth:   opts.MaxDepth,
+		maxEntries: opts.MaxEntries,
+		sem:        make(chan struct{}, workers),
+		ignore:     opts.ignoreList(),
+		mounts:     newMountFilter(opts),
+		seen:       make(map[string]bool),
+		start:      start,
+		next:       newIndex(indexKey(opts)),
+	}
+	d.next.GlobalConfig = globalConfigModTime()
+	if opts.Previous.Matches(opts) {
+		d.prev = opts.Previous
+		// Identities come from the global config too
+		d.reuseRepos = d.prev.GlobalConfig.Equal(d.next.GlobalConfig)
+	}
+
+	for _, path := range opts.Repositories {
+		root, err := FindRepoRoot(path)
+		if err != nil {
+			log.Printf("skip configured repository %s: %v", path, err)
+			d.addError()
+			continue
+		}
+		d.visit("", root, 0, d.ignore)
+	}
+
+	for _, root := range opts.Roots {
+		root = filepath.Clean(root)
+		stat, err := os.Stat(root)
+		if err != nil {
+			log.Printf("repository scan: skip root %s: %v", root, err)
+			continue
+		}
+		if !stat.IsDir() {
+			root = filepath.Dir(root)
+		}
+		d.visit(root, root, 0, d.ignore)
+	}
+	d.wg.Wait()
+
+	sort.Slice(d.repos, func(i, j int) bool { return d.repos[i].Path < d.repos[j].Path })
+	d.stats.Repositories = len(d.repos)
+	d.stats.Duration = time.Since(start)
+	if ctx.Err() != nil {
+		d.stats.Truncated = true
+	}
+	d.next.UpdatedAt = time.Now()
+	return d.repos, d.next, d.stats
+}
+
+// discovery is the shared state of one DiscoverRepositories run.
+type discovery struct {
+	ctx        context.Context
+	maxDepth   int
+	maxEntries int
+	ignore     ignoreList
+	mounts     *mountFilter
+	sem        chan struct{} // bounds concurrent directory walkers
+	wg         sync.WaitGroup
+
+	start      time.Time
+	prev       *Index // nil for a full walk
+	reuseRepos bool   // cached repository metadata is still valid
+
+	mu    sync.Mutex
+	seen  map[string]bool
+	repos []Info
+	stats DiscoveryStats
+	next  *Index
+}
+
+// ignoreList holds compiled DiscoveryOptions.Ignore patterns.
+type ignoreList []ignorePattern
+
+type ignorePattern struct {
+	base string         // for ignore file patterns, the directory they apply below
+	name string         // matches a directory name
+	path *regexp.Regexp // matches a path, relative to base when it is set
+	// builtin patterns come from DefaultIgnore and spare repositories
+	builtin bool
+}
+
+// ignoreList compiles the configured patterns plus, when enabled, the
+// built-in ones.
+func (opts DiscoveryOptions) ignoreList() ignoreList {
+	list := compileIgnore(opts.Ignore)
+	if opts.DefaultIgnore {
+		for _, pattern := range compileIgnore(DefaultIgnore) {
+			pattern.builtin = true
+			list = append(list, pattern)
+		}
+	}
+	return list
+}
+
+func compileIgnore(patterns []string) ignoreList {
+	var list ignoreList
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(strings.TrimSuffix(strings.TrimSpace(pattern), "/"))
+		if !strings.Contains(pattern, "/") {
+			list = append(list, ignorePattern{name: pattern})
+			continue
+		}
+		// Relative path patterns match below any directory, as in gitdir: conditions
+		if !strings.HasPrefix(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		list = append(list, ignorePattern{path: configGlob(pattern, false)})
+	}
+	return list
+}
+
+// match reports whether the directory at path, named name, is ignored.
+func (l ignoreList) match(path, name string) bool {
+	slashed := filepath.ToSlash(path)
+	for _, pattern := range l {
+		target := slashed
+		if pattern.base != "" {
+			rel, ok := strings.CutPrefix(path, pattern.base+string(filepath.Separator))
+			if !ok {
+				continue
+			}
+			target = filepath.ToSlash(rel)
+		}
+		var matched bool
+		if pattern.path != nil {
+			matched = pattern.path.MatchString(target)
+		} else {
+			matched, _ = filepath.Match(pattern.name, name)
+		}
+		if !matched {
+			continue
+		}
+		if pattern.builtin {
+			if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// visit handles one directory: it records a repository or walks its
+// children, handing them to idle workers when there are any and walking
+// them inline otherwise, so the pool never deadlocks. ignore holds the
+// configured patterns plus those of ignore files above path.
+func (d *discovery) visit(root, path string, depth int, ignore ignoreList) {
+	if d.ctx.Err() != nil {
+		return
+	}
+
+	d.mu.Lock()
+	if d.seen[path] {
+		d.mu.Unlock()
+		return
+	}
+	d.seen[path] = true
+	d.mu.Unlock()
+
+	// .git is a directory for regular repos and a file for worktrees and submodules
+	if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+		d.addRepo(root, path, depth)
+		return
+	}
+
+	if root == "" || (d.maxDepth > 0 && depth >= d.maxDepth) {
+		return
+	}
+	if patterns, ok := readIgnoreFile(path); ok {
+		ignore = ignore.within(path, patterns)
+	}
+
+	children, ok := d.children(path)
+	if !ok {
+		return
+	}
+
+	for _, name := range children {
+		childPath := filepath.Join(path, name)
+		if ignore.match(childPath, name) || d.mounts.skipped(childPath) {
+			d.mu.Lock()
+			d.stats.Ignored++
+			d.mu.Unlock()
+			continue
+		}
+
+		select {
+		case d.sem <- struct{}{}:
+			d.wg.Add(1)
+			go func() {
+				defer func() {
+					<-d.sem
+					d.wg.Done()
+				}()
+				d.visit(root, childPath, depth+1, ignore)
+			}()
+		default:
+			d.visit(root, childPath, depth+1, ignore)
+		}
+	}
+}
+
+// children lists the subdirectories of path, from the previous index when
+// the directory's mtime is unchanged.
+func (d *discovery) children(path string) ([]string, bool) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			d.addError()
+		}
+		return nil, false
+	}
+	mod := stat.ModTime()
+
+	if d.prev != nil && trusted(mod, d.start) {
+		if cached, ok := d.prev.Dirs[path]; ok && cached.ModTime.Equal(mod) {
+			d.mu.Lock()
+			d.stats.Cached++
+			d.next.Dirs[path] = cached
+			d.mu.Unlock()
+			return cached.Children, true
+		}
+	}
+
+	if !d.takeEntry() {
+		return nil, false
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			d.addError()
+		}
+		return nil, false
+	}
+
+	var children []string
+	for _, entry := range entries {
+		// Symlinked directories are skipped to avoid cycles
+		if entry.IsDir() && entry.Name() != ".git" {
+			children = append(children, entry.Name())
+		}
+	}
+
+	// A directory changed moments ago may change again within the same mtime tick
+	if !trusted(mod, d.start) {
+		mod = time.Time{}
+	}
+	d.mu.Lock()
+	d.next.Dirs[path] = IndexDir{ModTime: mod, Children: children}
+	d.mu.Unlock()
+	return children, true
+}
+
+func (d *discovery) addRepo(root, path string, depth int) {
+	if Excluded(path) {
+		d.mu.Lock()
+		d.stats.Ignored++
+		d.mu.Unlock()
+		return
+	}
+
+	stamp, _ := stampRepo(path)
+	entry, cached := d.cachedRepo(path, stamp)
+	if !cached {
+		info, includes, err := discover(path)
+		if err != nil {
+			log.Printf("discover repo %s: %v", path, err)
+			d.addError()
+			return
+		}
+		// The includes are only known after reading them, so an edit in
+		// between cannot be told apart from the content read
+		stamp.Includes = newestModTime(includes)
+		stamp.Racy = !stamp.Includes.IsZero() && !trusted(stamp.Includes, d.start)
+		entry = IndexRepo{Info: info, Stamp: stamp, Includes: includes}
+	}
+
+	d.mu.Lock()
+	d.repos = append(d.repos, entry.Info)
+	if cached {
+		d.stats.Cached++
+	}
+	d.next.Repos[path] = entry
+	d.mu.Unlock()
+
+	// Submodules live inside the repo, below where the walk stops
+	for _, sub := range TrackedSubmodules(path) {
+		d.visit(root, sub, depth+1, nil)
+	}
+}
+
+// cachedRepo returns the index entry of a repository whose git files and
+// included config have not changed since it was read.
+func (d *discovery) cachedRepo(path string, stamp repoStamp) (IndexRepo, bool) {
+	if d.prev == nil || !d.reuseRepos {
+		return IndexRepo{}, false
+	}
+	cached, ok := d.prev.Repos[path]
+	if !ok {
+		return IndexRepo{}, false
+	}
+	stamp.Includes = newestModTime(cached.Includes)
+	if !cached.Stamp.equal(stamp) {
+		return IndexRepo{}, false
+	}
+	for _, mod := range []time.Time{stamp.Git, stamp.Head, stamp.Config, stamp.Mailmap, stamp.Includes} {
+		if !mod.IsZero() && !trusted(mod, d.start) {
+			return IndexRepo{}, false
+		}
+	}
+	return cached, true
+}
+
+// takeEntry charges one directory read against the entry budget.
+func (d *discovery) takeEntry() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.maxEntries > 0 && d.stats.Directories >= d.maxEntries {
+		d.stats.Truncated = true
+		return false
+	}
+	d.stats.Directories++
+	return true
+}
+
+func (d *discovery) addError() {
+	d.mu.Lock()
+	d.stats.Errors++
+	d.mu.Unlock()
+}
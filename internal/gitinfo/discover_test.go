@@ -0,0 +1,142 @@
+package gitinfo
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// isolateGitConfig keeps the system and user git config out of a test.
+func isolateGitConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+}
+
+// makeRepo creates a minimal repository on main with the given config.
+func makeRepo(t *testing.T, path, config string) {
+	t.Helper()
+	writeFile(t, filepath.Join(path, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(path, ".git", "config"), config)
+}
+
+// backdate sets the mtime of everything below root, so discovery trusts it.
+func backdate(t *testing.T, root string, age time.Duration) {
+	t.Helper()
+	mod := time.Now().Add(-age)
+	err := filepath.WalkDir(root, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, mod, mod)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func repoPaths(infos []Info) []string {
+	paths := make([]string, 0, len(infos))
+	for _, info := range infos {
+		paths = append(paths, info.Path)
+	}
+	return paths
+}
+
+func TestDiscoverRescanReusesUnchangedDirectories(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	makeRepo(t, filepath.Join(root, "a", "api"), "")
+	makeRepo(t, filepath.Join(root, "b", "web"), "")
+	if err := os.Mkdir(filepath.Join(root, "c"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	backdate(t, root, time.Hour)
+	opts := DiscoveryOptions{Roots: []string{root}, Workers: 1}
+
+	infos, idx, stats := DiscoverRepositories(context.Background(), opts)
+	want := []string{filepath.Join(root, "a", "api"), filepath.Join(root, "b", "web")}
+	if got := repoPaths(infos); !slices.Equal(got, want) {
+		t.Fatalf("first scan found %q, want %q", got, want)
+	}
+	if stats.Directories != 4 || stats.Cached != 0 {
+		t.Errorf("first scan read %d directories and reused %d, want 4 and 0", stats.Directories, stats.Cached)
+	}
+
+	opts.Previous = idx
+	infos, idx, stats = DiscoverRepositories(context.Background(), opts)
+	if got := repoPaths(infos); !slices.Equal(got, want) {
+		t.Fatalf("rescan found %q, want %q", got, want)
+	}
+	if stats.Directories != 0 || stats.Cached != 6 {
+		t.Errorf("rescan read %d directories and reused %d, want 0 and 6", stats.Directories, stats.Cached)
+	}
+
+	// Only the directory that changed is read again
+	makeRepo(t, filepath.Join(root, "c", "cli"), "")
+	backdate(t, filepath.Join(root, "c"), 30*time.Minute)
+	opts.Previous = idx
+	infos, _, stats = DiscoverRepositories(context.Background(), opts)
+	want = append(want, filepath.Join(root, "c", "cli"))
+	if got := repoPaths(infos); !slices.Equal(got, want) {
+		t.Fatalf("rescan after a change found %q, want %q", got, want)
+	}
+	if stats.Directories != 1 {
+		t.Errorf("rescan after a change read %d directories, want 1", stats.Directories)
+	}
+}
+
+func TestDiscoverRereadsChangedIncludes(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	include := filepath.Join(t.TempDir(), "identity")
+	repo := filepath.Join(root, "api")
+	makeRepo(t, repo, "[include]\n\tpath = "+include+"\n")
+	writeFile(t, include, "[user]\n\tname = First\n\temail = me@example.com\n")
+	backdate(t, root, time.Hour)
+	backdate(t, include, time.Hour)
+	opts := DiscoveryOptions{Roots: []string{root}, Workers: 1}
+
+	infos, idx, _ := DiscoverRepositories(context.Background(), opts)
+	if len(infos) != 1 || infos[0].User != "First" {
+		t.Fatalf("first scan = %+v, want api by First", infos)
+	}
+	if got := idx.Repos[repo].Includes; !slices.Equal(got, []string{include}) {
+		t.Errorf("Includes = %q, want %q", got, []string{include})
+	}
+
+	writeFile(t, include, "[user]\n\tname = Second\n\temail = me@example.com\n")
+	backdate(t, include, 30*time.Minute)
+	opts.Previous = idx
+	infos, _, _ = DiscoverRepositories(context.Background(), opts)
+	if len(infos) != 1 || infos[0].User != "Second" {
+		t.Errorf("rescan = %+v, want api by Second", infos)
+	}
+}
+
+func TestDiscoverDoesNotReuseRacyIncludes(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	include := filepath.Join(t.TempDir(), "identity")
+	repo := filepath.Join(root, "api")
+	makeRepo(t, repo, "[include]\n\tpath = "+include+"\n")
+	backdate(t, root, time.Hour)
+	// Written just now, so a further edit could keep the same mtime
+	writeFile(t, include, "[user]\n\tname = First\n")
+	opts := DiscoveryOptions{Roots: []string{root}, Workers: 1}
+
+	_, idx, _ := DiscoverRepositories(context.Background(), opts)
+	if !idx.Repos[repo].Stamp.Racy {
+		t.Fatalf("stamp of a just-written include is not racy")
+	}
+
+	opts.Previous = idx
+	_, _, stats := DiscoverRepositories(context.Background(), opts)
+	if stats.Cached != 1 {
+		t.Errorf("rescan reused %d entries, want only the root directory", stats.Cached)
+	}
+}
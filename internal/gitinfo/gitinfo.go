@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,45 +15,138 @@ import (
 type Info struct {
 	Path   string
 	Name   string
+	ID     string // canonical identity shared by every clone, see repoID
 	Branch string
 	User   string
 	Email  string
-	Remote string
+	Remote string // origin, or the only remote when there is no origin
+	// Remotes holds the URL of every configured remote by name, e.g.
+	// origin and upstream of a fork.
+	Remotes map[string]string
+
+	Kind      string // KindRepository, KindWorktree or KindSubmodule
+	GitDir    string // per-worktree git directory
+	CommonDir string // git directory shared by all worktrees
+	MainPath  string // main working tree of a worktree, or superproject of a submodule
 }
 
 // Discover collects git metadata for the provided repository root.
 func Discover(path string) (Info, error) {
+	info, _, err := discover(path)
+	return info, err
+}
+
+// discover is Discover that also returns the files outside the git directory
+// the identity was read from: config includes and the mailmap.file target.
+func discover(path string) (Info, []string, error) {
 	root, err := FindRepoRoot(path)
 	if err != nil {
-		return Info{}, err
+		return Info{}, nil, err
 	}
 
 	branch, err := CurrentBranch(root)
 	if err != nil {
-		return Info{}, err
+		return Info{}, nil, err
 	}
 
-	user, _ := gitString(root, "config", "--get", "user.name")
-	email, _ := gitString(root, "config", "--get", "user.email")
-	remote, _ := gitString(root, "config", "--get", "remote.origin.url")
+	l, err := resolveLayout(root)
+	if err != nil {
+		return Info{}, nil, err
+	}
+
+	cfg, included := readConfigFiles(configFiles(l), l, branch)
+	if file := mailmapFile(root, cfg); file != "" {
+		included = append(included, file)
+	}
+	user, email := identity(root, cfg)
+	remotes := remotesFromConfig(cfg)
+	remote, ok := remotes["origin"]
+	if !ok && len(remotes) == 1 {
+		for _, url := range remotes {
+			remote = url
+		}
+	}
+
+	// Linked worktrees roll up to the repository they were created from
+	name := filepath.Base(root)
+	if l.kind == KindWorktree && l.mainPath != "" {
+		name = strings.TrimSuffix(filepath.Base(l.mainPath), ".git")
+	}
 
 	return Info{
-		Path:   root,
-		Name:   filepath.Base(root),
-		Branch: branch,
-		User:   user,
-		Email:  email,
-		Remote: remote,
-	}, nil
+		Path:      root,
+		Name:      name,
+		ID:        repoID(root, l, remote),
+		Branch:    branch,
+		User:      user,
+		Email:     email,
+		Remote:    remote,
+		Remotes:   remotes,
+		Kind:      l.kind,
+		GitDir:    l.gitDir,
+		CommonDir: l.commonDir,
+		MainPath:  l.mainPath,
+	}, included, nil
 }
 
-// CurrentBranch returns the name of the currently checked-out branch.
+// globalIdentityCache remembers GlobalIdentity until a file it was read
+// from changes.
+var globalIdentityCache struct {
+	sync.Mutex
+	files   []string // shared config, its includes and mailmap.file
+	modTime time.Time
+	user    string
+	email   string
+}
+
+// GlobalIdentity returns the user name and email from the system and global
+// git config, for activity that has no local repository to read them from.
+func GlobalIdentity() (user, email string) {
+	c := &globalIdentityCache
+	c.Lock()
+	defer c.Unlock()
+
+	if c.files != nil && newestModTime(c.files).Equal(c.modTime) {
+		return c.user, c.email
+	}
+
+	files := sharedConfigFiles()
+	cfg, included := readConfigFiles(files, layout{}, "")
+	files = append(files, included...)
+	if file := mailmapFile("", cfg); file != "" {
+		files = append(files, file)
+	}
+	c.user, c.email = identity("", cfg)
+	c.modTime = newestModTime(files)
+	c.files = files
+	if !c.modTime.IsZero() && !trusted(c.modTime, time.Now()) {
+		// Too recent to rely on; read again next time
+		c.files = nil
+	}
+	return c.user, c.email
+}
+
+// identity resolves the author identity git commits with in a working tree:
+// author.name and author.email take precedence over user.name and
+// user.email, and the result is mapped through the mailmap.
+func identity(root string, cfg gitConfig) (user, email string) {
+	user = orDefault(cfg.get("author.name"), cfg.get("user.name"))
+	email = orDefault(cfg.get("author.email"), cfg.get("user.email"))
+	if email == "" {
+		return user, email
+	}
+	return loadMailmap(root, cfg).resolve(user, email)
+}
+
+// CurrentBranch returns the name of the currently checked-out branch, or
+// "HEAD" when detached. During a rebase or bisect it is the branch being
+// rebased or bisected, see GetHeadState.
 func CurrentBranch(path string) (string, error) {
-	branch, err := gitString(path, "rev-parse", "--abbrev-ref", "HEAD")
+	state, err := GetHeadState(path)
 	if err != nil {
-		return "", fmt.Errorf("git branch: %w", err)
+		return "", err
 	}
-	return branch, nil
+	return state.Branch, nil
 }
 
 func ensureGitRepo(path string) error {
@@ -110,10 +204,77 @@ type Commit struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// commitCache remembers git log results per repo, start and HEAD so an
+// unchanged HEAD never runs git log twice.
+var commitCache = struct {
+	sync.Mutex
+	entries map[string]commitCacheEntry // keyed by repo path
+}{entries: make(map[string]commitCacheEntry)}
+
+type commitCacheEntry struct {
+	startHash string
+	headHash  string
+	commits   []Commit
+}
+
 // GetCommitsSince retrieves all commits from startHash to HEAD.
 // If startHash is empty, returns only the HEAD commit.
 // Returns commits in chronological order (oldest first).
+//
+// HEAD is resolved natively and commits made locally are rebuilt from the
+// reflog; git log only runs when HEAD moved some other way (pull, rebase).
 func GetCommitsSince(repoPath string, startHash string) ([]Commit, error) {
+	l, err := resolveLayout(repoPath)
+	if err != nil {
+		return getCommitsSinceExec(repoPath, startHash)
+	}
+	ref, headHash, err := readHead(l.gitDir)
+	if err == nil && ref != "" {
+		headHash, err = resolveRef(l, ref)
+	}
+	if err != nil {
+		return getCommitsSinceExec(repoPath, startHash)
+	}
+	if startHash != "" && headHash == startHash {
+		return []Commit{}, nil
+	}
+
+	commitCache.Lock()
+	cached, ok := commitCache.entries[repoPath]
+	commitCache.Unlock()
+	if ok && cached.startHash == startHash && cached.headHash == headHash {
+		return cached.commits, nil
+	}
+
+	var commits []Commit
+	reflogOK := false
+	if startHash != "" {
+		branch := strings.TrimPrefix(ref, "refs/heads/")
+		if ref == "" {
+			ref = "HEAD"
+		}
+		commits, reflogOK = commitsFromReflog(l, ref, startHash, headHash)
+		if reflogOK {
+			// git log applies the mailmap itself
+			m := loadMailmap(repoPath, readConfig(l, branch))
+			for i := range commits {
+				commits[i].Author = m.resolveAuthor(commits[i].Author)
+			}
+		}
+	}
+	if !reflogOK {
+		if commits, err = getCommitsSinceExec(repoPath, startHash); err != nil {
+			return nil, err
+		}
+	}
+
+	commitCache.Lock()
+	commitCache.entries[repoPath] = commitCacheEntry{startHash: startHash, headHash: headHash, commits: commits}
+	commitCache.Unlock()
+	return commits, nil
+}
+
+func getCommitsSinceExec(repoPath string, startHash string) ([]Commit, error) {
 	var gitRange string
 	if startHash == "" {
 		gitRange = "HEAD"
@@ -122,8 +283,9 @@ func GetCommitsSince(repoPath string, startHash string) ([]Commit, error) {
 	}
 
 	// Format: hash|author|timestamp|message (one line per commit)
-	// Use --reverse to get chronological order (oldest first)
-	output, err := gitString(repoPath, "log", "--reverse", "--pretty=format:%H|%an <%ae>|%aI|%s", gitRange)
+	// Use --reverse to get chronological order (oldest first); %aN and %aE
+	// apply the mailmap
+	output, err := gitString(repoPath, "log", "--reverse", "--pretty=format:%H|%aN <%aE>|%aI|%s", gitRange)
 	if err != nil {
 		return nil, fmt.Errorf("git log: %w", err)
 	}
@@ -159,9 +321,40 @@ func GetCommitsSince(repoPath string, startHash string) ([]Commit, error) {
 
 // GetCurrentCommitHash returns the current HEAD commit hash.
 func GetCurrentCommitHash(repoPath string) (string, error) {
+	if l, err := resolveLayout(repoPath); err == nil {
+		ref, hash, err := readHead(l.gitDir)
+		if err == nil && ref != "" {
+			hash, err = resolveRef(l, ref)
+		}
+		if err == nil {
+			return hash, nil
+		}
+	}
+
 	hash, err := gitString(repoPath, "rev-parse", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("get HEAD hash: %w", err)
 	}
 	return hash, nil
 }
+
+// GetPreviousHeadHash returns the commit HEAD pointed at before its latest
+// update, read from the HEAD reflog; it is "" when HEAD was unborn.
+func GetPreviousHeadHash(repoPath string) (string, error) {
+	l, err := resolveLayout(repoPath)
+	if err != nil {
+		return "", err
+	}
+	entries, err := readReflog(l, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("empty HEAD reflog")
+	}
+	previous := entries[len(entries)-1].oldHash
+	if strings.Trim(previous, "0") == "" {
+		return "", nil
+	}
+	return previous, nil
+}
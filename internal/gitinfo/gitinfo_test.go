@@ -0,0 +1,43 @@
+package gitinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGlobalIdentityCachedUntilConfigChanges(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "gitconfig")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+	globalIdentityCache.Lock()
+	globalIdentityCache.files = nil
+	globalIdentityCache.Unlock()
+
+	write := func(content string, mod time.Time) {
+		t.Helper()
+		writeFile(t, global, content)
+		if err := os.Chtimes(global, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(wantUser, wantEmail string) {
+		t.Helper()
+		if user, email := GlobalIdentity(); user != wantUser || email != wantEmail {
+			t.Errorf("GlobalIdentity() = (%q, %q), want (%q, %q)", user, email, wantUser, wantEmail)
+		}
+	}
+
+	mod := time.Now().Add(-time.Hour)
+	write("[user]\n\tname = First\n\temail = first@example.com\n", mod)
+	check("First", "first@example.com")
+
+	// An unchanged mtime is served from the cache without reading the file
+	write("[user]\n\tname = Second\n\temail = second@example.com\n", mod)
+	check("First", "first@example.com")
+
+	write("[user]\n\tname = Second\n\temail = second@example.com\n", mod.Add(time.Minute))
+	check("Second", "second@example.com")
+}
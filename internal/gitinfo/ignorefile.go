@@ -0,0 +1,81 @@
+package gitinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the per-directory ignore file honoured by discovery. Its
+// patterns apply below the directory holding it, like a .gitignore: plain
+// names match at any depth, patterns with a slash are relative to that
+// directory and "**" crosses directories. In a repository, an empty file
+// opts the repository out of tracking and patterns skip its submodules.
+const IgnoreFileName = ".awagentignore"
+
+// readIgnoreFile returns the patterns of dir's ignore file and whether there
+// is one. Blank lines and "#" comments are skipped; "!" negation is not
+// supported and such lines are dropped.
+func readIgnoreFile(dir string) ([]string, bool) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, true
+}
+
+// within returns l extended with patterns scoped to dir. l itself is not
+// modified, as sibling directories share it.
+func (l ignoreList) within(dir string, patterns []string) ignoreList {
+	if len(patterns) == 0 {
+		return l
+	}
+	scoped := make(ignoreList, len(l), len(l)+len(patterns))
+	copy(scoped, l)
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(strings.TrimSuffix(pattern, "/"))
+		if !strings.Contains(pattern, "/") {
+			scoped = append(scoped, ignorePattern{base: dir, name: pattern})
+			continue
+		}
+		scoped = append(scoped, ignorePattern{base: dir, path: configGlob(strings.TrimPrefix(pattern, "/"), false)})
+	}
+	return scoped
+}
+
+// Excluded reports whether a repository opted out of tracking with an empty
+// ignore file in its root.
+func Excluded(repoPath string) bool {
+	patterns, ok := readIgnoreFile(repoPath)
+	return ok && len(patterns) == 0
+}
+
+// TrackedSubmodules lists the initialised submodules of a repository that
+// its ignore file doesn't skip.
+func TrackedSubmodules(root string) []string {
+	subs := Submodules(root)
+	patterns, _ := readIgnoreFile(root)
+	if len(patterns) == 0 {
+		return subs
+	}
+	ignore := ignoreList(nil).within(root, patterns)
+	tracked := subs[:0]
+	for _, sub := range subs {
+		if !ignore.match(sub, filepath.Base(sub)) {
+			tracked = append(tracked, sub)
+		}
+	}
+	return tracked
+}
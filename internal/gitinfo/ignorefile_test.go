@@ -0,0 +1,85 @@
+package gitinfo
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, IgnoreFileName), "# scratch space\n\nscratch/\n  clients/legacy  \n!keep\n")
+
+	patterns, ok := readIgnoreFile(dir)
+	if !ok {
+		t.Fatal("readIgnoreFile found no file")
+	}
+	if want := []string{"scratch/", "clients/legacy"}; !slices.Equal(patterns, want) {
+		t.Errorf("patterns = %q, want %q", patterns, want)
+	}
+	if _, ok := readIgnoreFile(t.TempDir()); ok {
+		t.Error("readIgnoreFile reported a file in an empty directory")
+	}
+}
+
+func TestDiscoverHonoursIgnoreFiles(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, IgnoreFileName), "scratch\nclients/legacy\n")
+	writeFile(t, filepath.Join(root, "work", IgnoreFileName), "archive/**/old\n")
+	for _, repo := range []string{
+		"api",
+		"scratch/try",                   // name pattern at the top
+		"work/scratch/try",              // and at any depth
+		"clients/legacy/portal",         // path pattern relative to the ignore file
+		"work/clients/legacy/portal",    // which doesn't match deeper down
+		"work/archive/2023/old/billing", // "**" crosses directories
+		"work/archive/2023/new/billing",
+		"optout",
+	} {
+		makeRepo(t, filepath.Join(root, filepath.FromSlash(repo)), "")
+	}
+	// An empty ignore file opts a repository out
+	writeFile(t, filepath.Join(root, "optout", IgnoreFileName), "")
+
+	infos, _, stats := DiscoverRepositories(context.Background(), DiscoveryOptions{Roots: []string{root}, Workers: 1})
+	want := []string{
+		filepath.Join(root, "api"),
+		filepath.Join(root, "work", "archive", "2023", "new", "billing"),
+		filepath.Join(root, "work", "clients", "legacy", "portal"),
+	}
+	if got := repoPaths(infos); !slices.Equal(got, want) {
+		t.Errorf("found %q, want %q", got, want)
+	}
+	if stats.Ignored != 5 {
+		t.Errorf("Ignored = %d, want 5", stats.Ignored)
+	}
+}
+
+func TestTrackedSubmodulesHonoursIgnoreFile(t *testing.T) {
+	repo := t.TempDir()
+	makeRepo(t, repo, "")
+	writeFile(t, filepath.Join(repo, ".gitmodules"), `[submodule "a"]
+	path = libs/a
+[submodule "b"]
+	path = libs/b
+[submodule "c"]
+	path = libs/c
+`)
+	writeFile(t, filepath.Join(repo, "libs", "a", ".git"), "gitdir: ../../.git/modules/a\n")
+	writeFile(t, filepath.Join(repo, "libs", "b", ".git"), "gitdir: ../../.git/modules/b\n")
+	// libs/c is not initialised
+
+	if got, want := TrackedSubmodules(repo), []string{filepath.Join(repo, "libs", "a"), filepath.Join(repo, "libs", "b")}; !slices.Equal(got, want) {
+		t.Errorf("TrackedSubmodules = %q, want %q", got, want)
+	}
+
+	writeFile(t, filepath.Join(repo, IgnoreFileName), "libs/b\n")
+	if got, want := TrackedSubmodules(repo), []string{filepath.Join(repo, "libs", "a")}; !slices.Equal(got, want) {
+		t.Errorf("TrackedSubmodules with libs/b ignored = %q, want %q", got, want)
+	}
+	if Excluded(repo) {
+		t.Error("a repository with ignore patterns is excluded")
+	}
+}
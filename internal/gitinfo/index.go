@@ -0,0 +1,201 @@
+package gitinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexVersion changes whenever the on-disk index layout does.
+const indexVersion = 6
+
+// racyWindow is how recently a directory may have changed before its mtime
+// is not trusted: a later change within the same timestamp tick would go
+// unnoticed.
+const racyWindow = 2 * time.Second
+
+// Index is the persisted result of a discovery run. Rescans reuse the
+// children of directories whose mtime is unchanged and the metadata of
+// repositories whose git files are unchanged.
+type Index struct {
+	Version   int       `json:"version"`
+	Key       string    `json:"key"` // fingerprint of the options that shaped the walk
+	UpdatedAt time.Time `json:"updatedAt"`
+	// GlobalConfig is the newest mtime of the system and global git config;
+	// when it changes cached identities are re-read.
+	GlobalConfig time.Time            `json:"globalConfig"`
+	Dirs         map[string]IndexDir  `json:"dirs"`
+	Repos        map[string]IndexRepo `json:"repos"`
+}
+
+// IndexDir records the subdirectories of a walked directory.
+type IndexDir struct {
+	ModTime  time.Time `json:"modTime"`
+	Children []string  `json:"children,omitempty"`
+}
+
+// IndexRepo records a repository and the modification times of the git files
+// its metadata was read from.
+type IndexRepo struct {
+	Info  Info      `json:"info"`
+	Stamp repoStamp `json:"stamp"`
+	// Includes are the config includes and mailmap.file the identity was
+	// read from.
+	Includes []string `json:"includes,omitempty"`
+}
+
+type repoStamp struct {
+	Git      time.Time `json:"git"`      // .git directory or file
+	Head     time.Time `json:"head"`     // HEAD
+	HeadRef  string    `json:"headRef"`  // branch or commit HEAD points at
+	Config   time.Time `json:"config"`   // repository config
+	Mailmap  time.Time `json:"mailmap"`  // .mailmap of the working tree
+	Includes time.Time `json:"includes"` // newest of IndexRepo.Includes
+	// Racy is set when an include changed too recently to trust its mtime,
+	// so the stamp never matches a later one.
+	Racy bool `json:"racy,omitempty"`
+}
+
+func (s repoStamp) equal(o repoStamp) bool {
+	return s.Git.Equal(o.Git) && s.Head.Equal(o.Head) && s.HeadRef == o.HeadRef && s.Config.Equal(o.Config) && s.Mailmap.Equal(o.Mailmap) &&
+		s.Includes.Equal(o.Includes) && s.Racy == o.Racy
+}
+
+func newIndex(key string) *Index {
+	return &Index{
+		Version: indexVersion,
+		Key:     key,
+		Dirs:    make(map[string]IndexDir),
+		Repos:   make(map[string]IndexRepo),
+	}
+}
+
+// Infos returns the indexed repositories sorted by path.
+func (idx *Index) Infos() []Info {
+	infos := make([]Info, 0, len(idx.Repos))
+	for _, repo := range idx.Repos {
+		infos = append(infos, repo.Info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos
+}
+
+// Matches reports whether the index was built with equivalent options.
+func (idx *Index) Matches(opts DiscoveryOptions) bool {
+	return idx != nil && idx.Version == indexVersion && idx.Key == indexKey(opts)
+}
+
+// indexKey fingerprints the options that decide which directories are walked.
+func indexKey(opts DiscoveryOptions) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "roots=%s\nrepos=%s\ndepth=%d\nignore=%s\ndefaultIgnore=%t\n",
+		strings.Join(opts.Roots, "\x00"), strings.Join(opts.Repositories, "\x00"), opts.MaxDepth, strings.Join(opts.Ignore, "\x00"), opts.DefaultIgnore)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// LoadIndex reads an index written by SaveIndex.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read repository index: %w", err)
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("parse repository index: %w", err)
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("repository index version %d, want %d", idx.Version, indexVersion)
+	}
+	if idx.Dirs == nil {
+		idx.Dirs = make(map[string]IndexDir)
+	}
+	if idx.Repos == nil {
+		idx.Repos = make(map[string]IndexRepo)
+	}
+	return &idx, nil
+}
+
+// SaveIndex writes the index atomically.
+func SaveIndex(path string, idx *Index) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create index directory: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("encode repository index: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".repos-*.json")
+	if err != nil {
+		return fmt.Errorf("write repository index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write repository index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write repository index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write repository index: %w", err)
+	}
+	return nil
+}
+
+// stampRepo reads the modification times that decide whether cached
+// repository metadata is still valid.
+func stampRepo(path string) (repoStamp, bool) {
+	dotGit, err := os.Lstat(filepath.Join(path, ".git"))
+	if err != nil {
+		return repoStamp{}, false
+	}
+	stamp := repoStamp{Git: dotGit.ModTime()}
+	if mailmap, err := os.Stat(filepath.Join(path, ".mailmap")); err == nil {
+		stamp.Mailmap = mailmap.ModTime()
+	}
+
+	l, err := resolveLayout(path)
+	if err != nil {
+		return stamp, true
+	}
+	if head, err := os.Stat(filepath.Join(l.gitDir, "HEAD")); err == nil {
+		stamp.Head = head.ModTime()
+	}
+	// A checkout within the same mtime tick still changes which
+	// includeIf "onbranch:" sections apply
+	ref, hash, _ := readHead(l.gitDir)
+	stamp.HeadRef = orDefault(ref, hash)
+	if cfg, err := os.Stat(filepath.Join(l.commonDir, "config")); err == nil {
+		stamp.Config = cfg.ModTime()
+	}
+	return stamp, true
+}
+
+// globalConfigModTime returns the newest mtime of the config files shared by
+// every repository.
+func globalConfigModTime() time.Time {
+	return newestModTime(sharedConfigFiles())
+}
+
+// newestModTime returns the newest mtime of the files that exist.
+func newestModTime(files []string) time.Time {
+	var newest time.Time
+	for _, file := range files {
+		if stat, err := os.Stat(file); err == nil && stat.ModTime().After(newest) {
+			newest = stat.ModTime()
+		}
+	}
+	return newest
+}
+
+// trusted reports whether a modification time is old enough to rely on.
+func trusted(mod, scanStart time.Time) bool {
+	return !mod.IsZero() && scanStart.Sub(mod) >= racyWindow
+}
@@ -0,0 +1,62 @@
+package gitinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStampRepoTracksHeadTarget(t *testing.T) {
+	repo := t.TempDir()
+	head := filepath.Join(repo, ".git", "HEAD")
+	writeFile(t, head, "ref: refs/heads/main\n")
+	mod := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(head, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+
+	before, ok := stampRepo(repo)
+	if !ok {
+		t.Fatal("stampRepo found no repository")
+	}
+
+	// A checkout within the same timestamp tick leaves the mtime unchanged
+	writeFile(t, head, "ref: refs/heads/release/1.0\n")
+	if err := os.Chtimes(head, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+
+	after, _ := stampRepo(repo)
+	if after.HeadRef != "refs/heads/release/1.0" {
+		t.Errorf("HeadRef = %q, want refs/heads/release/1.0", after.HeadRef)
+	}
+	if before.equal(after) {
+		t.Error("stamps before and after switching branches are equal")
+	}
+}
+
+func TestLoadIndexRejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repos.json")
+	opts := DiscoveryOptions{Roots: []string{"/src"}}
+
+	idx := newIndex(indexKey(opts))
+	if err := SaveIndex(path, idx); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if !loaded.Matches(opts) {
+		t.Error("reloaded index does not match the options it was built with")
+	}
+
+	idx.Version = indexVersion - 1
+	if err := SaveIndex(path, idx); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	if _, err := LoadIndex(path); err == nil {
+		t.Errorf("LoadIndex accepted an index of version %d", idx.Version)
+	}
+}
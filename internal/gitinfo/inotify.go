@@ -0,0 +1,20 @@
+package gitinfo
+
+// fsEvent is a directory entry change reported by the platform notifier.
+type fsEvent struct {
+	wd   int
+	name string
+	op   uint32
+}
+
+// Notifier event flags, mapped from the platform's own.
+const (
+	fsCreate uint32 = 1 << iota
+	fsDelete
+	fsMovedFrom
+	fsMovedTo
+	fsCloseWrite
+	fsIsDir
+	fsIgnored  // the watch was removed, e.g. because its directory was deleted
+	fsOverflow // events were dropped
+)
@@ -0,0 +1,123 @@
+package gitinfo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Repository kinds reported in Info.Kind.
+const (
+	KindRepository = "repository"
+	KindWorktree   = "worktree"
+	KindSubmodule  = "submodule"
+	KindRemote     = "remote" // remote development workspace without a local clone
+)
+
+// layout describes where a working tree keeps its git data.
+type layout struct {
+	kind      string
+	gitDir    string // per-worktree git dir (HEAD, index)
+	commonDir string // shared objects, refs and config
+	mainPath  string // main working tree for worktrees, superproject for submodules
+}
+
+// resolveLayout inspects root/.git, following "gitdir:" files used by linked
+// worktrees and submodules.
+func resolveLayout(root string) (layout, error) {
+	dotGit := filepath.Join(root, ".git")
+	stat, err := os.Stat(dotGit)
+	if err != nil {
+		return layout{}, fmt.Errorf("stat .git: %w", err)
+	}
+
+	if stat.IsDir() {
+		return layout{kind: KindRepository, gitDir: dotGit, commonDir: dotGit}, nil
+	}
+
+	gitDir, err := readGitDirFile(dotGit)
+	if err != nil {
+		return layout{}, err
+	}
+
+	l := layout{kind: KindRepository, gitDir: gitDir, commonDir: gitDir}
+
+	// Linked worktrees point at <common>/worktrees/<name> which holds a commondir file
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		l.kind = KindWorktree
+		l.commonDir = filepath.Clean(common)
+		l.mainPath = mainWorktreePath(l.commonDir)
+		return l, nil
+	}
+
+	// Submodules keep their git dir under the superproject's .git/modules
+	if idx := strings.LastIndex(filepath.ToSlash(gitDir), "/.git/modules/"); idx >= 0 {
+		l.kind = KindSubmodule
+		if super, err := FindRepoRoot(filepath.Dir(root)); err == nil {
+			l.mainPath = super
+		} else {
+			l.mainPath = filepath.FromSlash(filepath.ToSlash(gitDir)[:idx])
+		}
+	}
+
+	return l, nil
+}
+
+// readGitDirFile parses a ".git" file of the form "gitdir: <path>".
+func readGitDirFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read .git file: %w", err)
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("invalid .git file %s", path)
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// mainWorktreePath returns the main working tree for a common git dir, or the
+// common dir itself for bare repositories.
+func mainWorktreePath(commonDir string) string {
+	if filepath.Base(commonDir) == ".git" {
+		return filepath.Dir(commonDir)
+	}
+	return commonDir
+}
+
+// Submodules lists the working-tree paths of initialised submodules declared
+// in the repository's .gitmodules.
+func Submodules(root string) []string {
+	f, err := os.Open(filepath.Join(root, ".gitmodules"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "path" {
+			continue
+		}
+		sub := filepath.Join(root, filepath.FromSlash(strings.TrimSpace(value)))
+		if _, err := os.Stat(filepath.Join(sub, ".git")); err == nil {
+			paths = append(paths, sub)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,148 @@
+package gitinfo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mailmap maps the identities commits were made with to canonical ones, as
+// git's .mailmap does. Entries are keyed by lowercased commit email.
+type mailmap map[string]*mailmapEntry
+
+type mailmapEntry struct {
+	name, email string
+	// byName holds entries that also match the commit name, keyed by
+	// lowercased name.
+	byName map[string]mailmapIdentity
+}
+
+type mailmapIdentity struct {
+	name, email string
+}
+
+// loadMailmap reads the mailmap of a working tree: its .mailmap, then the
+// file named by mailmap.file, with later entries winning as in git.
+// mailmap.blob is not read; it only matters for bare repositories.
+func loadMailmap(root string, cfg gitConfig) mailmap {
+	m := make(mailmap)
+	if root != "" {
+		m.read(filepath.Join(root, ".mailmap"))
+	}
+	if file := mailmapFile(root, cfg); file != "" {
+		m.read(file)
+	}
+	return m
+}
+
+// mailmapFile returns the path mailmap.file names, "" when it is unset.
+func mailmapFile(root string, cfg gitConfig) string {
+	file := cfg.get("mailmap.file")
+	if file == "" {
+		return ""
+	}
+	// Expands "~/" and resolves relative paths against the working tree
+	return includePath(file, filepath.Join(root, ".mailmap"))
+}
+
+func (m mailmap) read(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		m.add(line)
+	}
+}
+
+// add parses one mailmap line, one of:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func (m mailmap) add(line string) {
+	properName, properEmail, rest, ok := parseMailmapIdent(line)
+	if !ok {
+		return
+	}
+	commitName, commitEmail, _, ok := parseMailmapIdent(rest)
+	if !ok {
+		// A single address maps that address to the name
+		commitEmail, properEmail = properEmail, ""
+	}
+
+	key := strings.ToLower(commitEmail)
+	entry := m[key]
+	if entry == nil {
+		entry = &mailmapEntry{}
+		m[key] = entry
+	}
+	if commitName == "" {
+		if properName != "" {
+			entry.name = properName
+		}
+		if properEmail != "" {
+			entry.email = properEmail
+		}
+		return
+	}
+	if entry.byName == nil {
+		entry.byName = make(map[string]mailmapIdentity)
+	}
+	entry.byName[strings.ToLower(commitName)] = mailmapIdentity{name: properName, email: properEmail}
+}
+
+// parseMailmapIdent reads an optional name followed by an <email>.
+func parseMailmapIdent(s string) (name, email, rest string, ok bool) {
+	open := strings.IndexByte(s, '<')
+	if open < 0 {
+		return "", "", "", false
+	}
+	end := strings.IndexByte(s[open:], '>')
+	if end < 0 {
+		return "", "", "", false
+	}
+	return strings.TrimSpace(s[:open]), strings.TrimSpace(s[open+1 : open+end]), s[open+end+1:], true
+}
+
+// resolve returns the canonical name and email; parts the mailmap does not
+// replace are returned unchanged.
+func (m mailmap) resolve(name, email string) (string, string) {
+	entry := m[strings.ToLower(email)]
+	if entry == nil {
+		return name, email
+	}
+	if id, ok := entry.byName[strings.ToLower(name)]; ok {
+		return orDefault(id.name, name), orDefault(id.email, email)
+	}
+	return orDefault(entry.name, name), orDefault(entry.email, email)
+}
+
+// resolveAuthor applies the mailmap to a "Name <email>" string.
+func (m mailmap) resolveAuthor(author string) string {
+	if len(m) == 0 {
+		return author
+	}
+	name, email, _, ok := parseMailmapIdent(author)
+	if !ok {
+		return author
+	}
+	name, email = m.resolve(name, email)
+	return name + " <" + email + ">"
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package gitinfo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMailmapResolve(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".mailmap"), `# Forms, in the order git documents them
+Jane Doe <jane@old.example>
+<jane@example.com> <JANE@Laptop.Local>
+Joe Dev <joe@example.com> <joe@old.example>
+Build Bot <bot@example.com> ci <shared@example.com>
+Release Bot <release@example.com> Release <shared@example.com> # trailing comment
+malformed line without address
+`)
+	m := loadMailmap(root, gitConfig{})
+
+	tests := []struct {
+		name      string
+		email     string
+		wantName  string
+		wantEmail string
+	}{
+		{name: "jane", email: "jane@old.example", wantName: "Jane Doe", wantEmail: "jane@old.example"},
+		{name: "Jane", email: "jane@laptop.local", wantName: "Jane", wantEmail: "jane@example.com"},
+		{name: "joe", email: "Joe@Old.Example", wantName: "Joe Dev", wantEmail: "joe@example.com"},
+		{name: "CI", email: "shared@example.com", wantName: "Build Bot", wantEmail: "bot@example.com"},
+		{name: "release", email: "shared@example.com", wantName: "Release Bot", wantEmail: "release@example.com"},
+		{name: "someone", email: "shared@example.com", wantName: "someone", wantEmail: "shared@example.com"},
+		{name: "Stranger", email: "stranger@example.com", wantName: "Stranger", wantEmail: "stranger@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.email, func(t *testing.T) {
+			name, email := m.resolve(tt.name, tt.email)
+			if name != tt.wantName || email != tt.wantEmail {
+				t.Errorf("resolve(%q, %q) = (%q, %q), want (%q, %q)", tt.name, tt.email, name, email, tt.wantName, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestMailmapFileOverridesRepoMailmap(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".mailmap"), "Repo Name <me@example.com>\n")
+	writeFile(t, filepath.Join(root, "tools", "mailmap"), "Config Name <me@example.com>\n")
+
+	m := loadMailmap(root, gitConfig{"mailmap.file": {"tools/mailmap"}})
+	if name, _ := m.resolve("me", "me@example.com"); name != "Config Name" {
+		t.Errorf("resolve name = %q, want the mailmap.file entry", name)
+	}
+}
@@ -0,0 +1,63 @@
+package gitinfo
+
+import (
+	"log"
+	"path/filepath"
+	"runtime"
+)
+
+// DefaultSkipFilesystems lists filesystem types whose mounts discovery does
+// not enter: network and FUSE filesystems are slow to walk, and kernel
+// pseudo filesystems never hold repositories.
+var DefaultSkipFilesystems = []string{
+	"nfs", "nfs4", "cifs", "smb3", "smbfs", "afs", "ceph", "davfs", "fuse.*",
+	"proc", "sysfs", "devtmpfs", "devpts", "cgroup", "cgroup2", "tracefs", "debugfs",
+	"securityfs", "pstore", "bpf", "autofs", "mqueue", "hugetlbfs", "configfs", "fusectl", "binfmt_misc",
+}
+
+// mountFilter decides which mount points below a root discovery enters.
+type mountFilter struct {
+	mounts map[string]string // mount point -> filesystem type
+	oneFS  bool
+	skip   []string
+}
+
+// newMountFilter reads the mount table, or returns nil when the options
+// don't restrict mounts or the table can't be read. Platform-specific
+// implementations are in mounts_linux.go and mounts_other.go
+func newMountFilter(opts DiscoveryOptions) *mountFilter {
+	if !opts.OneFilesystem && len(opts.SkipFilesystems) == 0 {
+		return nil
+	}
+	switch runtime.GOOS {
+	case "linux":
+		mounts, err := readMountsLinux()
+		if err != nil {
+			log.Printf("repository scan: mount rules not applied: %v", err)
+			return nil
+		}
+		return &mountFilter{mounts: mounts, oneFS: opts.OneFilesystem, skip: opts.SkipFilesystems}
+	default:
+		return nil
+	}
+}
+
+// skipped reports whether path is a mount point the walk must not enter.
+func (f *mountFilter) skipped(path string) bool {
+	if f == nil {
+		return false
+	}
+	fsType, ok := f.mounts[path]
+	if !ok {
+		return false
+	}
+	if f.oneFS {
+		return true
+	}
+	for _, pattern := range f.skip {
+		if matched, _ := filepath.Match(pattern, fsType); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package gitinfo
+
+import (
+	"fmt"
+)
+
+// Stubs for other platforms (not compiled on Linux)
+func readMountsLinux() (map[string]string, error) {
+	return nil, fmt.Errorf("Linux not supported")
+}
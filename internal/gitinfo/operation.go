@@ -0,0 +1,110 @@
+package gitinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Operations that can be in progress in a working tree.
+const (
+	OperationRebase     = "rebase"
+	OperationAm         = "am"
+	OperationMerge      = "merge"
+	OperationCherryPick = "cherry-pick"
+	OperationRevert     = "revert"
+	OperationBisect     = "bisect"
+)
+
+// HeadState describes what a working tree has checked out.
+type HeadState struct {
+	// Branch is the logical branch: the branch being rebased or the one a
+	// bisect started from while they detach HEAD, else the checked-out
+	// branch, or "HEAD" when detached.
+	Branch string
+	// Operation is the git operation in progress, "" when there is none.
+	Operation string
+}
+
+// GetHeadState reads HEAD and the state files git keeps while a rebase, am,
+// merge, cherry-pick, revert or bisect is in progress. Everything is read
+// directly; git is only run when the repository layout can't be read.
+func GetHeadState(path string) (HeadState, error) {
+	l, err := resolveLayout(path)
+	if err == nil {
+		var ref string
+		if ref, _, err = readHead(l.gitDir); err == nil {
+			state := HeadState{Branch: "HEAD"}
+			if ref != "" {
+				state.Branch = strings.TrimPrefix(ref, "refs/heads/")
+			}
+			state.Operation, ref = operationInProgress(l.gitDir)
+			if ref != "" {
+				state.Branch = ref
+			}
+			return state, nil
+		}
+	}
+
+	branch, err := gitString(path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return HeadState{}, fmt.Errorf("git branch: %w", err)
+	}
+	return HeadState{Branch: branch}, nil
+}
+
+// operationInProgress checks the state files in git's precedence order and
+// returns the operation with the branch it detached HEAD from, if any.
+func operationInProgress(gitDir string) (operation, branch string) {
+	if exists(filepath.Join(gitDir, "rebase-merge")) {
+		return OperationRebase, rebaseHeadName(filepath.Join(gitDir, "rebase-merge"))
+	}
+	if dir := filepath.Join(gitDir, "rebase-apply"); exists(dir) {
+		if exists(filepath.Join(dir, "applying")) {
+			// git am leaves HEAD on the branch
+			return OperationAm, ""
+		}
+		return OperationRebase, rebaseHeadName(dir)
+	}
+	switch {
+	case exists(filepath.Join(gitDir, "MERGE_HEAD")):
+		return OperationMerge, ""
+	case exists(filepath.Join(gitDir, "CHERRY_PICK_HEAD")):
+		return OperationCherryPick, ""
+	case exists(filepath.Join(gitDir, "REVERT_HEAD")):
+		return OperationRevert, ""
+	case exists(filepath.Join(gitDir, "BISECT_LOG")):
+		// BISECT_START holds the branch, or a commit when started detached
+		start := readStateFile(filepath.Join(gitDir, "BISECT_START"))
+		if isHash(start) {
+			start = ""
+		}
+		return OperationBisect, strings.TrimPrefix(start, "refs/heads/")
+	}
+	return "", ""
+}
+
+// rebaseHeadName returns the branch being rebased, or "" when the rebase
+// started from a detached HEAD.
+func rebaseHeadName(dir string) string {
+	name, ok := strings.CutPrefix(readStateFile(filepath.Join(dir, "head-name")), "refs/heads/")
+	if !ok {
+		// "detached HEAD"
+		return ""
+	}
+	return name
+}
+
+func readStateFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,113 @@
+package gitinfo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetHeadStateOperations(t *testing.T) {
+	const hash = "1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name      string
+		head      string
+		files     map[string]string // relative to .git
+		branch    string
+		operation string
+	}{
+		{
+			name:   "no operation",
+			head:   "ref: refs/heads/main",
+			branch: "main",
+		},
+		{
+			name:      "interactive rebase",
+			head:      hash,
+			files:     map[string]string{"rebase-merge/head-name": "refs/heads/feature/x"},
+			branch:    "feature/x",
+			operation: OperationRebase,
+		},
+		{
+			name:      "rebase of a detached HEAD",
+			head:      hash,
+			files:     map[string]string{"rebase-merge/head-name": "detached HEAD"},
+			branch:    "HEAD",
+			operation: OperationRebase,
+		},
+		{
+			name:      "apply rebase",
+			head:      hash,
+			files:     map[string]string{"rebase-apply/head-name": "refs/heads/topic", "rebase-apply/rebasing": ""},
+			branch:    "topic",
+			operation: OperationRebase,
+		},
+		{
+			name:      "am",
+			head:      "ref: refs/heads/main",
+			files:     map[string]string{"rebase-apply/applying": ""},
+			branch:    "main",
+			operation: OperationAm,
+		},
+		{
+			name:      "merge",
+			head:      "ref: refs/heads/main",
+			files:     map[string]string{"MERGE_HEAD": hash},
+			branch:    "main",
+			operation: OperationMerge,
+		},
+		{
+			name:      "cherry-pick",
+			head:      "ref: refs/heads/main",
+			files:     map[string]string{"CHERRY_PICK_HEAD": hash},
+			branch:    "main",
+			operation: OperationCherryPick,
+		},
+		{
+			name:      "revert",
+			head:      "ref: refs/heads/main",
+			files:     map[string]string{"REVERT_HEAD": hash},
+			branch:    "main",
+			operation: OperationRevert,
+		},
+		{
+			name:      "bisect from a branch",
+			head:      hash,
+			files:     map[string]string{"BISECT_LOG": "", "BISECT_START": "refs/heads/release"},
+			branch:    "release",
+			operation: OperationBisect,
+		},
+		{
+			name:      "bisect from a detached HEAD",
+			head:      hash,
+			files:     map[string]string{"BISECT_LOG": "", "BISECT_START": hash},
+			branch:    "HEAD",
+			operation: OperationBisect,
+		},
+		{
+			name:      "rebase takes precedence over merge",
+			head:      hash,
+			files:     map[string]string{"rebase-merge/head-name": "refs/heads/main", "MERGE_HEAD": hash},
+			branch:    "main",
+			operation: OperationRebase,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := t.TempDir()
+			gitDir := filepath.Join(repo, ".git")
+			writeFile(t, filepath.Join(gitDir, "HEAD"), tt.head+"\n")
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(gitDir, filepath.FromSlash(name)), content+"\n")
+			}
+
+			got, err := GetHeadState(repo)
+			if err != nil {
+				t.Fatalf("GetHeadState: %v", err)
+			}
+			if got.Branch != tt.branch || got.Operation != tt.operation {
+				t.Errorf("GetHeadState = %+v, want branch %q operation %q", got, tt.branch, tt.operation)
+			}
+		})
+	}
+}
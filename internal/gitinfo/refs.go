@@ -0,0 +1,224 @@
+package gitinfo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRefDepth bounds symbolic ref chains.
+const maxRefDepth = 5
+
+// readHead returns the ref HEAD points at, or "" with the commit hash when
+// HEAD is detached.
+func readHead(gitDir string) (ref, hash string, err error) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", "", fmt.Errorf("read HEAD: %w", err)
+	}
+	line := strings.TrimSpace(string(data))
+	if target, ok := strings.CutPrefix(line, "ref:"); ok {
+		return strings.TrimSpace(target), "", nil
+	}
+	if !isHash(line) {
+		return "", "", fmt.Errorf("invalid HEAD %q", line)
+	}
+	return "", line, nil
+}
+
+// resolveRef follows a ref to a commit hash through loose refs (per-worktree
+// refs in gitDir first) and packed-refs.
+func resolveRef(l layout, ref string) (string, error) {
+	for depth := 0; depth < maxRefDepth; depth++ {
+		value, err := readLooseRef(l, ref)
+		if err != nil {
+			return readPackedRef(l.commonDir, ref)
+		}
+		target, symbolic := strings.CutPrefix(value, "ref:")
+		if !symbolic {
+			if !isHash(value) {
+				return "", fmt.Errorf("invalid ref %s: %q", ref, value)
+			}
+			return value, nil
+		}
+		ref = strings.TrimSpace(target)
+	}
+	return "", fmt.Errorf("ref %s: symbolic ref chain too deep", ref)
+}
+
+func readLooseRef(l layout, ref string) (string, error) {
+	dirs := []string{l.commonDir}
+	if !strings.HasPrefix(ref, "refs/") || strings.HasPrefix(ref, "refs/bisect/") || strings.HasPrefix(ref, "refs/worktree/") {
+		dirs = []string{l.gitDir, l.commonDir}
+	}
+	var lastErr error
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// readPackedRef looks a ref up in packed-refs.
+func readPackedRef(commonDir, ref string) (string, error) {
+	f, err := os.Open(filepath.Join(commonDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("ref %s not found", ref)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if ok && name == ref && isHash(hash) {
+			return hash, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read packed-refs: %w", err)
+	}
+	return "", fmt.Errorf("ref %s not found", ref)
+}
+
+// reflogEntry is one line of a reflog file.
+type reflogEntry struct {
+	oldHash string
+	newHash string
+	author  string // "Name <email>"
+	when    time.Time
+	message string
+}
+
+// readReflog parses the reflog of a ref, oldest entry first.
+func readReflog(l layout, ref string) ([]reflogEntry, error) {
+	dir := l.commonDir
+	if ref == "HEAD" {
+		dir = l.gitDir
+	}
+	f, err := os.Open(filepath.Join(dir, "logs", filepath.FromSlash(ref)))
+	if err != nil {
+		return nil, fmt.Errorf("open reflog: %w", err)
+	}
+	defer f.Close()
+
+	var entries []reflogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if entry, ok := parseReflogLine(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read reflog: %w", err)
+	}
+	return entries, nil
+}
+
+// parseReflogLine parses "<old> <new> Name <email> <unix> <tz>\t<message>".
+func parseReflogLine(line string) (reflogEntry, bool) {
+	head, message, _ := strings.Cut(line, "\t")
+	fields := strings.SplitN(head, " ", 3)
+	if len(fields) != 3 || !isHash(fields[0]) || !isHash(fields[1]) {
+		return reflogEntry{}, false
+	}
+
+	ident := fields[2]
+	end := strings.LastIndex(ident, ">")
+	if end < 0 {
+		return reflogEntry{}, false
+	}
+	tail := strings.Fields(ident[end+1:])
+	if len(tail) != 2 {
+		return reflogEntry{}, false
+	}
+	unix, err := strconv.ParseInt(tail[0], 10, 64)
+	if err != nil {
+		return reflogEntry{}, false
+	}
+
+	return reflogEntry{
+		oldHash: fields[0],
+		newHash: fields[1],
+		author:  strings.TrimSpace(ident[:end+1]),
+		when:    time.Unix(unix, 0).In(parseTZ(tail[1])),
+		message: message,
+	}, true
+}
+
+// parseTZ converts a "+0700" style offset into a fixed zone.
+func parseTZ(tz string) *time.Location {
+	if len(tz) != 5 {
+		return time.UTC
+	}
+	hours, err1 := strconv.Atoi(tz[1:3])
+	minutes, err2 := strconv.Atoi(tz[3:5])
+	if err1 != nil || err2 != nil {
+		return time.UTC
+	}
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(tz, offset)
+}
+
+// commitsFromReflog rebuilds the commits made on a ref since startHash from
+// its reflog. It only succeeds when every step since startHash was a local
+// commit, so merges, pulls and rebases fall back to git log. The reflog
+// identity is the committer, which is the author for local commits.
+func commitsFromReflog(l layout, ref, startHash, headHash string) ([]Commit, bool) {
+	entries, err := readReflog(l, ref)
+	if err != nil || len(entries) == 0 || entries[len(entries)-1].newHash != headHash {
+		return nil, false
+	}
+
+	var commits []Commit
+	want := headHash
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.newHash != want {
+			return nil, false
+		}
+		subject, ok := strings.CutPrefix(entry.message, "commit: ")
+		if !ok {
+			subject, ok = strings.CutPrefix(entry.message, "commit (initial): ")
+		}
+		if !ok {
+			return nil, false
+		}
+		commits = append(commits, Commit{Hash: entry.newHash, Message: subject, Author: entry.author, Timestamp: entry.when})
+		if entry.oldHash == startHash {
+			// Oldest first, like git log --reverse
+			for a, b := 0, len(commits)-1; a < b; a, b = a+1, b-1 {
+				commits[a], commits[b] = commits[b], commits[a]
+			}
+			return commits, true
+		}
+		want = entry.oldHash
+	}
+	return nil, false
+}
+
+func isHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package gitinfo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRef(t *testing.T) {
+	const (
+		mainHash    = "1111111111111111111111111111111111111111"
+		tagHash     = "2222222222222222222222222222222222222222"
+		peeledHash  = "3333333333333333333333333333333333333333"
+		looseHash   = "4444444444444444444444444444444444444444"
+		packedStale = "5555555555555555555555555555555555555555"
+	)
+
+	gitDir := filepath.Join(t.TempDir(), ".git")
+	writeFile(t, filepath.Join(gitDir, "packed-refs"), `# pack-refs with: peeled fully-peeled sorted 
+`+mainHash+` refs/heads/main
+`+packedStale+` refs/heads/topic
+`+tagHash+` refs/tags/v1.0
+^`+peeledHash+`
+`)
+	writeFile(t, filepath.Join(gitDir, "refs", "heads", "topic"), looseHash+"\n")
+	writeFile(t, filepath.Join(gitDir, "refs", "remotes", "origin", "HEAD"), "ref: refs/heads/main\n")
+	l := layout{kind: KindRepository, gitDir: gitDir, commonDir: gitDir}
+
+	tests := []struct {
+		ref  string
+		want string
+		ok   bool
+	}{
+		{ref: "refs/heads/main", want: mainHash, ok: true},
+		{ref: "refs/heads/topic", want: looseHash, ok: true},
+		{ref: "refs/tags/v1.0", want: tagHash, ok: true},
+		{ref: "refs/remotes/origin/HEAD", want: mainHash, ok: true},
+		{ref: "refs/heads/missing", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := resolveRef(l, tt.ref)
+			if (err == nil) != tt.ok {
+				t.Fatalf("resolveRef(%q) error = %v, want ok %v", tt.ref, err, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("resolveRef(%q) = %q, want %q", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadHead(t *testing.T) {
+	const hash = "1111111111111111111111111111111111111111"
+
+	tests := []struct {
+		name string
+		head string
+		ref  string
+		hash string
+		ok   bool
+	}{
+		{name: "branch", head: "ref: refs/heads/main\n", ref: "refs/heads/main", ok: true},
+		{name: "detached", head: hash + "\n", hash: hash, ok: true},
+		{name: "garbage", head: "not a ref\n", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitDir := t.TempDir()
+			writeFile(t, filepath.Join(gitDir, "HEAD"), tt.head)
+
+			ref, got, err := readHead(gitDir)
+			if (err == nil) != tt.ok {
+				t.Fatalf("readHead error = %v, want ok %v", err, tt.ok)
+			}
+			if ref != tt.ref || got != tt.hash {
+				t.Errorf("readHead = (%q, %q), want (%q, %q)", ref, got, tt.ref, tt.hash)
+			}
+		})
+	}
+}
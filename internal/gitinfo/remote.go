@@ -0,0 +1,69 @@
+package gitinfo
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeRemoteURL reduces ssh, https and scp-style remote URLs to a
+// lowercase "host/org/repo" form so different clones of a repository compare
+// equal. Local paths are returned cleaned but otherwise unchanged.
+func NormalizeRemoteURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	var host, path string
+	switch {
+	case strings.Contains(raw, "://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return strings.ToLower(strings.TrimSuffix(raw, ".git"))
+		}
+		if u.Scheme == "file" {
+			return strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git")
+		}
+		host = u.Hostname()
+		path = u.Path
+	case isSCPLike(raw):
+		// [user@]host:org/repo.git; an "@" after the colon is part of the path
+		colon := strings.Index(raw, ":")
+		host = raw[:colon]
+		if at := strings.Index(host, "@"); at >= 0 {
+			host = host[at+1:]
+		}
+		path = raw[colon+1:]
+	default:
+		return strings.TrimSuffix(strings.TrimSuffix(raw, "/"), ".git")
+	}
+
+	path = strings.Trim(path, "/")
+	path = strings.TrimSuffix(path, ".git")
+	return strings.ToLower(host + "/" + path)
+}
+
+// isSCPLike reports whether raw uses git's "[user@]host:path" shorthand.
+func isSCPLike(raw string) bool {
+	colon := strings.Index(raw, ":")
+	if colon <= 0 || isWindowsPath(raw) {
+		return false
+	}
+	slash := strings.Index(raw, "/")
+	// A slash before the colon means a local path such as ./a:b
+	return slash < 0 || slash > colon
+}
+
+// isWindowsPath reports whether raw is a Windows path: it starts with a drive
+// letter ("C:\repo", "C:/work/repo") or contains backslashes. A single
+// letter is never taken for an scp-style host.
+func isWindowsPath(raw string) bool {
+	if strings.Contains(raw, `\`) {
+		return true
+	}
+	if len(raw) < 2 || raw[1] != ':' {
+		return false
+	}
+	letter := raw[0] | 0x20
+	return letter >= 'a' && letter <= 'z'
+}
@@ -0,0 +1,57 @@
+package gitinfo
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// rootCommits caches root commit hashes by common git directory; history
+// below the root never changes, so entries are kept for the process
+// lifetime.
+var rootCommits sync.Map
+
+// RemoteID returns the canonical identity a remote URL gives a repository,
+// "host/org/repo", or "" for local paths that don't identify a project.
+func RemoteID(remote string) string {
+	id := NormalizeRemoteURL(remote)
+	if id == "" || filepath.IsAbs(id) || isWindowsPath(id) || strings.HasPrefix(id, ".") || !strings.Contains(id, "/") {
+		return ""
+	}
+	return id
+}
+
+// repoID derives the canonical identity of a repository: its normalized
+// origin URL, or "root:" and the root commit hash when it has no network
+// remote. Clones of the same project share it wherever they are checked
+// out; a repository without remote or commits has none.
+func repoID(root string, l layout, remote string) string {
+	if id := RemoteID(remote); id != "" {
+		return id
+	}
+	if hash := rootCommit(root, l); hash != "" {
+		return "root:" + hash
+	}
+	return ""
+}
+
+// rootCommit returns the oldest parentless commit reachable from HEAD.
+func rootCommit(root string, l layout) string {
+	if cached, ok := rootCommits.Load(l.commonDir); ok {
+		return cached.(string)
+	}
+	out, err := gitString(root, "rev-list", "--max-parents=0", "HEAD")
+	if err != nil {
+		// No commits yet; try again once there are
+		return ""
+	}
+	// Histories joined from several projects have several roots; the
+	// oldest is listed last
+	lines := strings.Fields(out)
+	if len(lines) == 0 || !isHash(lines[len(lines)-1]) {
+		return ""
+	}
+	hash := lines[len(lines)-1]
+	rootCommits.Store(l.commonDir, hash)
+	return hash
+}
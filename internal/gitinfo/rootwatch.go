@@ -0,0 +1,288 @@
+package gitinfo
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// rootSettle is how long a new repository is left to settle before it is
+// reported, so a clone has written HEAD and config by the time it is read.
+const rootSettle = 2 * time.Second
+
+// RootEvent reports a repository appearing or disappearing under a scan root.
+type RootEvent struct {
+	Path string
+	// Removed is set when the directory at Path was deleted or moved away;
+	// every repository at or below it is gone.
+	Removed bool
+}
+
+// RootWatcher watches the directories of the scan roots, down to the
+// discovery depth, so clones, git init and deleted checkouts are seen
+// straight away instead of on the next rescan. Repositories themselves are
+// not entered. Platform-specific implementations are in watch_linux.go and
+// watch_other.go
+type RootWatcher struct {
+	events     chan RootEvent
+	roots      []string
+	maxDepth   int
+	ignore     ignoreList
+	mounts     *mountFilter
+	maxWatches int
+
+	mu        sync.Mutex
+	dirs      map[int]rootDir // watch descriptor -> directory
+	paths     map[string]int  // directory -> watch descriptor
+	pending   map[string]*time.Timer
+	exhausted bool
+	closed    bool
+
+	in *inotify
+}
+
+type rootDir struct {
+	path   string
+	depth  int        // levels below its root
+	ignore ignoreList // patterns that apply to its children
+}
+
+// NewRootWatcher starts watching the roots in opts, using at most
+// maxWatches directory watches (0 is unlimited). The initial walk runs in
+// the background.
+func NewRootWatcher(opts DiscoveryOptions, maxWatches int) (*RootWatcher, error) {
+	w := &RootWatcher{
+		events:     make(chan RootEvent, 64),
+		maxDepth:   opts.MaxDepth,
+		ignore:     opts.ignoreList(),
+		mounts:     newMountFilter(opts),
+		maxWatches: maxWatches,
+		dirs:       make(map[int]rootDir),
+		paths:      make(map[string]int),
+		pending:    make(map[string]*time.Timer),
+	}
+	for _, root := range opts.Roots {
+		if stat, err := os.Stat(root); err == nil && stat.IsDir() {
+			w.roots = append(w.roots, filepath.Clean(root))
+		}
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		in, err := newInotifyLinux(w.dispatch)
+		if err != nil {
+			return nil, err
+		}
+		w.in = in
+	default:
+		return nil, fmt.Errorf("root watching unsupported on %s", runtime.GOOS)
+	}
+
+	go func() {
+		start := time.Now()
+		for _, root := range w.roots {
+			w.watchTree(root, 0, w.ignore, false)
+		}
+		w.mu.Lock()
+		watched := len(w.dirs)
+		w.mu.Unlock()
+		log.Printf("Watching %d directories under %d roots for new repositories (%s)", watched, len(w.roots), time.Since(start).Round(time.Millisecond))
+	}()
+	return w, nil
+}
+
+// Events delivers repositories that appeared or disappeared.
+func (w *RootWatcher) Events() <-chan RootEvent {
+	return w.events
+}
+
+// Close stops watching the roots.
+func (w *RootWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	for _, timer := range w.pending {
+		timer.Stop()
+	}
+	return w.in.close()
+}
+
+// watchTree watches path and the directories below it up to the depth
+// limit, with ignore holding the patterns that apply to path's children.
+// When announce is set, repositories found on the way are reported as new.
+func (w *RootWatcher) watchTree(path string, depth int, ignore ignoreList, announce bool) {
+	if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+		if announce {
+			w.candidate(path)
+		}
+		return
+	}
+	if w.maxDepth > 0 && depth >= w.maxDepth {
+		// Too deep to watch, but it may still become a repository
+		if announce {
+			w.candidate(path)
+		}
+		return
+	}
+	if patterns, ok := readIgnoreFile(path); ok {
+		ignore = ignore.within(path, patterns)
+	}
+	// Watch before listing so entries created in between aren't missed
+	if !w.watch(path, depth, ignore) {
+		return
+	}
+	if announce {
+		// A clone may write .git before the watch was in place
+		w.candidate(path)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		// Symlinked directories are skipped to avoid cycles
+		if !entry.IsDir() || entry.Name() == ".git" {
+			continue
+		}
+		child := filepath.Join(path, entry.Name())
+		if ignore.match(child, entry.Name()) || w.mounts.skipped(child) {
+			continue
+		}
+		w.watchTree(child, depth+1, ignore, announce)
+	}
+}
+
+// watch adds a directory watch, within the watch budget.
+func (w *RootWatcher) watch(path string, depth int, ignore ignoreList) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	if _, ok := w.paths[path]; ok {
+		return true
+	}
+	if w.maxWatches > 0 && len(w.dirs) >= w.maxWatches {
+		w.budgetExhaustedLocked(fmt.Sprintf("the budget of %d watches is used up", w.maxWatches))
+		return false
+	}
+
+	wd, err := w.in.add(path, fsCreate|fsDelete|fsMovedFrom|fsMovedTo)
+	if err != nil {
+		if errors.Is(err, syscall.ENOSPC) {
+			w.budgetExhaustedLocked("the kernel watch limit (fs.inotify.max_user_watches) is reached")
+		}
+		return false
+	}
+	w.dirs[wd] = rootDir{path: path, depth: depth, ignore: ignore}
+	w.paths[path] = wd
+	return true
+}
+
+func (w *RootWatcher) budgetExhaustedLocked(reason string) {
+	if w.exhausted {
+		return
+	}
+	w.exhausted = true
+	log.Printf("Root watcher: %s; new repositories in unwatched directories are picked up by the periodic rescan (raise git.maxRootWatches or add git.ignore patterns)", reason)
+}
+
+// unwatchTree drops the watches on path and every directory below it.
+func (w *RootWatcher) unwatchTree(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	prefix := path + string(filepath.Separator)
+	for dir, wd := range w.paths {
+		if dir == path || strings.HasPrefix(dir, prefix) {
+			w.in.remove(wd)
+			delete(w.paths, dir)
+			delete(w.dirs, wd)
+		}
+	}
+	for repo, timer := range w.pending {
+		if repo == path || strings.HasPrefix(repo, prefix) {
+			timer.Stop()
+			delete(w.pending, repo)
+		}
+	}
+}
+
+// candidate reports path as a new repository once it has settled, if it
+// holds a .git entry by then.
+func (w *RootWatcher) candidate(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.pending[path] != nil {
+		return
+	}
+	w.pending[path] = time.AfterFunc(rootSettle, func() {
+		w.mu.Lock()
+		delete(w.pending, path)
+		closed := w.closed
+		w.mu.Unlock()
+		if closed {
+			return
+		}
+		if _, err := os.Lstat(filepath.Join(path, ".git")); err != nil || Excluded(path) {
+			return
+		}
+		// Nothing below a repository is watched
+		w.unwatchTree(path)
+		w.send(RootEvent{Path: path})
+	})
+}
+
+func (w *RootWatcher) send(event RootEvent) {
+	select {
+	case w.events <- event:
+	default:
+		// The consumer is behind; the periodic rescan picks the change up
+	}
+}
+
+// dispatch routes a notifier event.
+func (w *RootWatcher) dispatch(event fsEvent) {
+	if event.op&fsOverflow != 0 {
+		log.Printf("Root watcher: event queue overflowed, new repositories may wait for the next rescan")
+		return
+	}
+
+	w.mu.Lock()
+	dir, ok := w.dirs[event.wd]
+	if ok && event.op&fsIgnored != 0 {
+		// The kernel dropped the watch because the directory went away
+		delete(w.dirs, event.wd)
+		if w.paths[dir.path] == event.wd {
+			delete(w.paths, dir.path)
+		}
+	}
+	w.mu.Unlock()
+	if !ok || event.name == "" || event.op&fsIgnored != 0 {
+		return
+	}
+
+	path := filepath.Join(dir.path, event.name)
+	switch {
+	case event.name == ".git" && event.op&(fsCreate|fsMovedTo) != 0:
+		// git init or a clone into an existing directory
+		w.candidate(dir.path)
+	case event.op&fsIsDir == 0 || event.name == ".git" || dir.ignore.match(path, event.name) || w.mounts.skipped(path):
+		// Only directories can hold repositories
+	case event.op&(fsCreate|fsMovedTo) != 0:
+		go w.watchTree(path, dir.depth+1, dir.ignore, true)
+	case event.op&(fsDelete|fsMovedFrom) != 0:
+		w.unwatchTree(path)
+		w.send(RootEvent{Path: path, Removed: true})
+	}
+}
@@ -0,0 +1,129 @@
+package gitinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tracking describes how a branch relates to the branch it tracks.
+type Tracking struct {
+	Upstream string // "origin/main", or a local branch name for remote "."
+	Remote   string // remote the upstream lives on, "" for a local upstream
+	// Ahead and Behind count commits not in the upstream and commits not
+	// yet merged from it. They are only set when Counted is.
+	Ahead   int
+	Behind  int
+	Counted bool
+}
+
+// trackingCache remembers ahead/behind counts per repo and branch so git
+// only runs when either side moved.
+var trackingCache = struct {
+	sync.Mutex
+	entries map[string]trackingCacheEntry // keyed by repo path and branch
+}{entries: make(map[string]trackingCacheEntry)}
+
+type trackingCacheEntry struct {
+	localHash    string
+	upstreamHash string
+	ahead        int
+	behind       int
+}
+
+// remotesFromConfig lists the URL of every configured remote by name.
+func remotesFromConfig(cfg gitConfig) map[string]string {
+	var remotes map[string]string
+	for key := range cfg {
+		name, ok := strings.CutPrefix(key, "remote.")
+		if !ok {
+			continue
+		}
+		if name, ok = strings.CutSuffix(name, ".url"); !ok || name == "" {
+			continue
+		}
+		if remotes == nil {
+			remotes = make(map[string]string)
+		}
+		remotes[name] = cfg.get(key)
+	}
+	return remotes
+}
+
+// GetTracking returns the upstream of a branch from branch.<name>.remote and
+// branch.<name>.merge, with ahead/behind counts when the upstream has been
+// fetched. Refs are resolved natively; git rev-list only runs when either
+// side moved since the last call. The zero Tracking means no upstream.
+func GetTracking(repoPath, branch string) (Tracking, error) {
+	if branch == "" || branch == "HEAD" {
+		return Tracking{}, nil
+	}
+	l, err := resolveLayout(repoPath)
+	if err != nil {
+		return Tracking{}, err
+	}
+	cfg := readConfig(l, branch)
+	remote := cfg.get("branch." + branch + ".remote")
+	merge := cfg.get("branch." + branch + ".merge")
+	if remote == "" || merge == "" {
+		return Tracking{}, nil
+	}
+
+	var tracking Tracking
+	var upstreamRef string
+	mergeBranch := strings.TrimPrefix(merge, "refs/heads/")
+	if remote == "." {
+		tracking.Upstream = mergeBranch
+		upstreamRef = merge
+	} else {
+		// Assumes the default fetch refspec, as almost every clone has
+		tracking.Upstream = remote + "/" + mergeBranch
+		tracking.Remote = remote
+		upstreamRef = "refs/remotes/" + remote + "/" + mergeBranch
+	}
+
+	localHash, err := resolveRef(l, "refs/heads/"+branch)
+	if err != nil {
+		// No commits on the branch yet
+		return tracking, nil
+	}
+	upstreamHash, err := resolveRef(l, upstreamRef)
+	if err != nil {
+		// Not fetched yet
+		return tracking, nil
+	}
+
+	key := repoPath + "\x00" + branch
+	trackingCache.Lock()
+	cached, ok := trackingCache.entries[key]
+	trackingCache.Unlock()
+	if ok && cached.localHash == localHash && cached.upstreamHash == upstreamHash {
+		tracking.Ahead, tracking.Behind, tracking.Counted = cached.ahead, cached.behind, true
+		return tracking, nil
+	}
+
+	if localHash == upstreamHash {
+		tracking.Counted = true
+	} else {
+		out, err := gitString(repoPath, "rev-list", "--left-right", "--count", localHash+"..."+upstreamHash)
+		if err != nil {
+			return tracking, err
+		}
+		fields := strings.Fields(out)
+		if len(fields) != 2 {
+			return tracking, fmt.Errorf("unexpected rev-list output %q", out)
+		}
+		ahead, err1 := strconv.Atoi(fields[0])
+		behind, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			return tracking, fmt.Errorf("unexpected rev-list output %q", out)
+		}
+		tracking.Ahead, tracking.Behind, tracking.Counted = ahead, behind, true
+	}
+
+	trackingCache.Lock()
+	trackingCache.entries[key] = trackingCacheEntry{localHash: localHash, upstreamHash: upstreamHash, ahead: tracking.Ahead, behind: tracking.Behind}
+	trackingCache.Unlock()
+	return tracking, nil
+}
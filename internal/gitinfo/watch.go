@@ -0,0 +1,267 @@
+package gitinfo
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchDebounce coalesces the burst of ref updates a single git command makes.
+const watchDebounce = 200 * time.Millisecond
+
+// Watcher reports repositories whose HEAD, branch refs or packed-refs change,
+// so checkouts and new commits are seen as they happen rather than on the
+// next poll. Platform-specific implementations are in watch_linux.go and
+// watch_other.go
+type Watcher struct {
+	changes chan string
+
+	mu      sync.Mutex
+	repos   map[string][]int // repo path -> watch descriptors
+	targets map[int][]watchTarget
+	timers  map[string]*time.Timer
+	closed  bool
+
+	in *inotify
+}
+
+// watchTarget is one repository's interest in a watched directory.
+type watchTarget struct {
+	repo string
+	dir  string
+	// names limits events to these entries; nil accepts every entry, as
+	// under refs/heads where any file is a branch.
+	names map[string]bool
+}
+
+// NewWatcher starts watching for git state changes.
+func NewWatcher() (*Watcher, error) {
+	w := &Watcher{
+		changes: make(chan string, 64),
+		repos:   make(map[string][]int),
+		targets: make(map[int][]watchTarget),
+		timers:  make(map[string]*time.Timer),
+	}
+	switch runtime.GOOS {
+	case "linux":
+		in, err := newInotifyLinux(w.dispatch)
+		if err != nil {
+			return nil, err
+		}
+		w.in = in
+		return w, nil
+	default:
+		return nil, fmt.Errorf("git state watching unsupported on %s", runtime.GOOS)
+	}
+}
+
+// Changes delivers the path of each repository whose git state changed.
+func (w *Watcher) Changes() <-chan string {
+	return w.changes
+}
+
+// Watching reports whether the repository is being watched.
+func (w *Watcher) Watching(repoPath string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.repos[repoPath]
+	return ok
+}
+
+// Repos lists the watched repository paths.
+func (w *Watcher) Repos() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	repos := make([]string, 0, len(w.repos))
+	for repo := range w.repos {
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
+// notify schedules a change notification for repo, coalescing bursts.
+func (w *Watcher) notify(repo string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.timers[repo] != nil {
+		return
+	}
+	w.timers[repo] = time.AfterFunc(watchDebounce, func() {
+		w.mu.Lock()
+		delete(w.timers, repo)
+		closed := w.closed
+		w.mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case w.changes <- repo:
+		default:
+			// The consumer is behind; the next poll picks the change up
+		}
+	})
+}
+
+// Add starts watching a repository's HEAD, branch refs and packed-refs. It is
+// a no-op for repositories already being watched.
+func (w *Watcher) Add(info Info) error {
+	if info.GitDir == "" || info.CommonDir == "" {
+		return fmt.Errorf("no git directory for %s", info.Path)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return fmt.Errorf("watcher closed")
+	}
+	if _, ok := w.repos[info.Path]; ok {
+		return nil
+	}
+	w.repos[info.Path] = nil
+
+	if err := w.addLocked(info); err != nil {
+		w.removeLocked(info.Path)
+		return err
+	}
+	return nil
+}
+
+func (w *Watcher) addLocked(info Info) error {
+	if info.GitDir == info.CommonDir {
+		if err := w.watchLocked(watchTarget{repo: info.Path, dir: info.GitDir, names: map[string]bool{"HEAD": true, "packed-refs": true}}); err != nil {
+			return err
+		}
+	} else {
+		if err := w.watchLocked(watchTarget{repo: info.Path, dir: info.GitDir, names: map[string]bool{"HEAD": true}}); err != nil {
+			return err
+		}
+		if err := w.watchLocked(watchTarget{repo: info.Path, dir: info.CommonDir, names: map[string]bool{"packed-refs": true}}); err != nil {
+			return err
+		}
+	}
+
+	// Branch names with slashes live in subdirectories of refs/heads
+	heads := filepath.Join(info.CommonDir, "refs", "heads")
+	return filepath.WalkDir(heads, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		return w.watchLocked(watchTarget{repo: info.Path, dir: path})
+	})
+}
+
+// Remove stops watching a repository.
+func (w *Watcher) Remove(repoPath string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeLocked(repoPath)
+}
+
+func (w *Watcher) removeLocked(repoPath string) {
+	for _, wd := range w.repos[repoPath] {
+		kept := w.targets[wd][:0]
+		for _, target := range w.targets[wd] {
+			if target.repo != repoPath {
+				kept = append(kept, target)
+			}
+		}
+		if len(kept) == 0 {
+			// Other repos (e.g. worktrees sharing refs) may still need the watch
+			w.in.remove(wd)
+			delete(w.targets, wd)
+		} else {
+			w.targets[wd] = kept
+		}
+	}
+	delete(w.repos, repoPath)
+	if timer := w.timers[repoPath]; timer != nil {
+		timer.Stop()
+		delete(w.timers, repoPath)
+	}
+}
+
+// Close stops watching all repositories.
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	return w.in.close()
+}
+
+// watchLocked adds a directory watch for target. The caller must hold w.mu.
+func (w *Watcher) watchLocked(target watchTarget) error {
+	wd, err := w.in.add(target.dir, fsCreate|fsCloseWrite|fsMovedTo|fsDelete)
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", target.dir, err)
+	}
+	w.targets[wd] = append(w.targets[wd], target)
+	w.repos[target.repo] = append(w.repos[target.repo], wd)
+	return nil
+}
+
+// dispatch routes a notifier event.
+func (w *Watcher) dispatch(event fsEvent) {
+	switch {
+	case event.op&fsIgnored != 0:
+		w.forget(event.wd)
+	case event.op&fsOverflow != 0:
+		log.Printf("Git watcher: event queue overflowed, changes may be noticed late")
+	case event.name != "":
+		w.handle(event.wd, event.name, event.op&fsCreate != 0 && event.op&fsIsDir != 0)
+	}
+}
+
+// handle dispatches one directory entry event to the repositories watching it.
+func (w *Watcher) handle(wd int, name string, createdDir bool) {
+	w.mu.Lock()
+	var changed []string
+	for _, target := range w.targets[wd] {
+		if createdDir {
+			if target.names == nil {
+				// New branch namespace directory under refs/heads
+				if err := w.watchLocked(watchTarget{repo: target.repo, dir: filepath.Join(target.dir, name)}); err != nil {
+					log.Printf("Git watcher: %v", err)
+				}
+				// The branch may have been written before the watch existed
+				changed = append(changed, target.repo)
+			}
+			continue
+		}
+		if strings.HasSuffix(name, ".lock") || (target.names != nil && !target.names[name]) {
+			continue
+		}
+		changed = append(changed, target.repo)
+	}
+	w.mu.Unlock()
+
+	for _, repo := range changed {
+		w.notify(repo)
+	}
+}
+
+// forget drops a watch the kernel removed because its directory went away.
+func (w *Watcher) forget(wd int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, target := range w.targets[wd] {
+		wds := w.repos[target.repo]
+		for i, candidate := range wds {
+			if candidate == wd {
+				w.repos[target.repo] = append(wds[:i], wds[i+1:]...)
+				break
+			}
+		}
+	}
+	delete(w.targets, wd)
+}
@@ -0,0 +1,110 @@
+//go:build linux
+// +build linux
+
+package gitinfo
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyFlags maps inotify mask bits to notifier event flags.
+var inotifyFlags = []struct{ mask, op uint32 }{
+	{syscall.IN_CREATE, fsCreate},
+	{syscall.IN_DELETE, fsDelete},
+	{syscall.IN_MOVED_FROM, fsMovedFrom},
+	{syscall.IN_MOVED_TO, fsMovedTo},
+	{syscall.IN_CLOSE_WRITE, fsCloseWrite},
+	{syscall.IN_ISDIR, fsIsDir},
+	{syscall.IN_IGNORED, fsIgnored},
+	{syscall.IN_Q_OVERFLOW, fsOverflow},
+}
+
+// inotify wraps an inotify instance and dispatches its events.
+type inotify struct {
+	fd   int
+	file *os.File
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newInotifyLinux(handle func(fsEvent)) (*inotify, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+	// A non-blocking descriptor goes through the runtime poller, so close
+	// unblocks the read loop
+	in := &inotify{fd: fd, file: os.NewFile(uintptr(fd), "inotify")}
+	go in.readLoop(handle)
+	return in, nil
+}
+
+func (in *inotify) add(dir string, ops uint32) (int, error) {
+	var mask uint32 = syscall.IN_ONLYDIR
+	for _, flag := range inotifyFlags {
+		if ops&flag.op != 0 {
+			mask |= flag.mask
+		}
+	}
+	return syscall.InotifyAddWatch(in.fd, dir, mask)
+}
+
+func (in *inotify) remove(wd int) {
+	_, _ = syscall.InotifyRmWatch(in.fd, uint32(wd))
+}
+
+func (in *inotify) close() error {
+	in.mu.Lock()
+	in.closed = true
+	in.mu.Unlock()
+	return in.file.Close()
+}
+
+func (in *inotify) readLoop(handle func(fsEvent)) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := in.file.Read(buf)
+		if err != nil {
+			in.mu.Lock()
+			closed := in.closed
+			in.mu.Unlock()
+			if !closed {
+				log.Printf("Filesystem watcher stopped: %v", err)
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(raw.Len)
+			if nameEnd > n {
+				break
+			}
+			offset = nameEnd
+
+			event := fsEvent{wd: int(raw.Wd), name: string(trimNUL(buf[nameStart:nameEnd]))}
+			for _, flag := range inotifyFlags {
+				if raw.Mask&flag.mask != 0 {
+					event.op |= flag.op
+				}
+			}
+			handle(event)
+		}
+	}
+}
+
+func trimNUL(b []byte) []byte {
+	for i, c := range b {
+		if c == 0 {
+			return b[:i]
+		}
+	}
+	return b
+}
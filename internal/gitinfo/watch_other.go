@@ -0,0 +1,25 @@
+//go:build !linux
+// +build !linux
+
+package gitinfo
+
+import (
+	"fmt"
+)
+
+// Stubs for other platforms (not compiled on Linux)
+type inotify struct{}
+
+func newInotifyLinux(handle func(fsEvent)) (*inotify, error) {
+	return nil, fmt.Errorf("Linux not supported")
+}
+
+func (in *inotify) add(dir string, ops uint32) (int, error) {
+	return 0, fmt.Errorf("Linux not supported")
+}
+
+func (in *inotify) remove(wd int) {}
+
+func (in *inotify) close() error {
+	return nil
+}
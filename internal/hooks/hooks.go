@@ -0,0 +1,277 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Names lists the git hooks that notify the agent.
+var Names = []string{"post-commit", "post-checkout", "post-merge", "post-rewrite"}
+
+// marker identifies hook scripts written by awagent.
+const marker = "# Installed by awagent"
+
+// chainSuffix is appended to an existing hook that an awagent hook replaced
+// and runs afterwards.
+const chainSuffix = ".awagent-orig"
+
+// previousHooksPathFile records the global core.hooksPath that a global
+// install replaced, so uninstall can restore it.
+const previousHooksPathFile = ".awagent-previous-hooks-path"
+
+// Notifier is the command line a hook runs to notify the agent, e.g. the
+// awagent executable and its --config flag.
+type Notifier []string
+
+// script renders a hook that notifies the agent in the background and then
+// runs the chained hook, a shell word, with the original arguments and stdin.
+func (n Notifier) script(name, chained string) string {
+	quoted := make([]string, len(n))
+	for i, arg := range n {
+		quoted[i] = shellQuote(arg)
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(marker + "; remove with \"awagent hooks uninstall\".\n")
+	fmt.Fprintf(&b, "%s hooks notify %s </dev/null >/dev/null 2>&1 &\n", strings.Join(quoted, " "), name)
+	fmt.Fprintf(&b, "if [ -x %[1]s ]; then exec %[1]s \"$@\"; fi\n", chained)
+	return b.String()
+}
+
+// InstallRepo installs the hooks into a repository's effective hooks
+// directory, which honours a repository-level core.hooksPath. Existing hooks
+// are kept and chained. A hooks directory inside the working tree, such as
+// husky's .husky, holds tracked files and is refused unless force is set.
+// It returns the hooks directory.
+func InstallRepo(repoPath string, notify Notifier, force bool) (string, error) {
+	dir, err := hooksDir(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if !force {
+		inside, err := inWorktree(repoPath, dir)
+		if err != nil {
+			return "", err
+		}
+		if inside {
+			return "", fmt.Errorf("hooks directory %s is inside the working tree; installing would change tracked files (use --force to install anyway)", dir)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create hooks directory: %w", err)
+	}
+
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		ours, err := isOurs(path)
+		if err != nil {
+			return "", err
+		}
+		if !ours {
+			if _, err := os.Stat(path); err == nil {
+				if err := os.Rename(path, path+chainSuffix); err != nil {
+					return "", fmt.Errorf("keep existing %s hook: %w", name, err)
+				}
+			}
+		}
+		if err := writeHook(path, notify.script(name, shellQuote(path+chainSuffix))); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// UninstallRepo removes the hooks from a repository and puts back the hooks
+// they replaced. It returns the hooks directory.
+func UninstallRepo(repoPath string) (string, error) {
+	dir, err := hooksDir(repoPath)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		ours, err := isOurs(path)
+		if err != nil || !ours {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return "", fmt.Errorf("remove %s hook: %w", name, err)
+		}
+		if _, err := os.Stat(path + chainSuffix); err == nil {
+			if err := os.Rename(path+chainSuffix, path); err != nil {
+				return "", fmt.Errorf("restore %s hook: %w", name, err)
+			}
+		}
+	}
+	return dir, nil
+}
+
+// InstallGlobal writes the hooks into dir and points the global
+// core.hooksPath at it. Because a global hooks path disables each
+// repository's own .git/hooks, the hooks chain to those, or to the hooks of
+// a global hooks path that was already set.
+func InstallGlobal(dir string, notify Notifier) error {
+	previous, err := gitConfigGlobal("--get", "core.hooksPath")
+	if err != nil {
+		return err
+	}
+	previous = expandHome(previous)
+	if filepath.Clean(previous) == filepath.Clean(dir) {
+		// Reinstalling; keep what the first install recorded
+		data, _ := os.ReadFile(filepath.Join(dir, previousHooksPathFile))
+		previous = strings.TrimSpace(string(data))
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, previousHooksPathFile), []byte(previous+"\n"), 0o644); err != nil {
+		return fmt.Errorf("record previous hooks path: %w", err)
+	}
+
+	for _, name := range Names {
+		// The repository's own hook, found relative to its common git dir
+		chained := `"$(git rev-parse --git-common-dir)/hooks/` + name + `"`
+		if previous != "" {
+			chained = shellQuote(filepath.Join(previous, name))
+		}
+		if err := writeHook(filepath.Join(dir, name), notify.script(name, chained)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := gitConfigGlobal("core.hooksPath", dir); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UninstallGlobal removes the hooks from dir and restores the global
+// core.hooksPath that was set before they were installed.
+func UninstallGlobal(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, previousHooksPathFile))
+	if err != nil {
+		return fmt.Errorf("no global awagent hooks in %s: %w", dir, err)
+	}
+	previous := strings.TrimSpace(string(data))
+
+	current, err := gitConfigGlobal("--get", "core.hooksPath")
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(expandHome(current)) == filepath.Clean(dir) {
+		if previous != "" {
+			_, err = gitConfigGlobal("core.hooksPath", previous)
+		} else {
+			_, err = gitConfigGlobal("--unset", "core.hooksPath")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		if ours, _ := isOurs(path); ours {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("remove %s hook: %w", name, err)
+			}
+		}
+	}
+	return os.Remove(filepath.Join(dir, previousHooksPathFile))
+}
+
+// hooksDir returns the directory git runs a repository's hooks from.
+func hooksDir(repoPath string) (string, error) {
+	repoPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("resolve repository path: %w", err)
+	}
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("find hooks directory of %s: %w", repoPath, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return dir, nil
+}
+
+// inWorktree reports whether dir lies inside the repository's working tree
+// but outside its .git directory.
+func inWorktree(repoPath, dir string) (bool, error) {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return false, fmt.Errorf("find working tree of %s: %w", repoPath, err)
+	}
+	top := strings.TrimSpace(string(out))
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(top); err == nil {
+		top = resolved
+	}
+	rel, err := filepath.Rel(top, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, nil
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return first != ".git", nil
+}
+
+// gitConfigGlobal runs git config --global; an unset key reads as "".
+func gitConfigGlobal(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"config", "--global"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 from --get means the key is not set
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return "", nil
+		}
+		return "", fmt.Errorf("git config --global %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func isOurs(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("read hook %s: %w", path, err)
+	}
+	return bytes.Contains(data, []byte(marker)), nil
+}
+
+func writeHook(path, script string) error {
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return fmt.Errorf("write hook %s: %w", path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0o755); err != nil {
+		return fmt.Errorf("write hook %s: %w", path, err)
+	}
+	return nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,185 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolateGit keeps the tests away from the user's git config.
+func isolateGit(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	return home
+}
+
+func git(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	git(t, "init", "-q", repo)
+	return repo
+}
+
+// recordingNotifier appends the hook it was called for to a file.
+func recordingNotifier(t *testing.T) (Notifier, string) {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "notified")
+	return Notifier{"/bin/sh", "-c", `echo "$3" >> "$0"`, out}, out
+}
+
+func writeScript(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readEventually(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil && len(data) > 0 {
+			return strings.TrimSpace(string(data))
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not written", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestInstallRepoChainsAndUninstallRestores(t *testing.T) {
+	isolateGit(t)
+	repo := initRepo(t)
+	hooksPath := filepath.Join(repo, ".git", "hooks")
+	chained := filepath.Join(t.TempDir(), "chained")
+	writeScript(t, filepath.Join(hooksPath, "post-commit"), `echo "$@" > `+shellQuote(chained))
+
+	notify, notified := recordingNotifier(t)
+	dir, err := InstallRepo(repo, notify, false)
+	if err != nil {
+		t.Fatalf("InstallRepo: %v", err)
+	}
+	if dir != hooksPath {
+		t.Errorf("hooks directory = %q, want %q", dir, hooksPath)
+	}
+	// Reinstalling must not chain the awagent hook to itself
+	if _, err := InstallRepo(repo, notify, false); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+
+	for _, name := range Names {
+		if ours, err := isOurs(filepath.Join(hooksPath, name)); err != nil || !ours {
+			t.Errorf("%s not installed: ours=%v err=%v", name, ours, err)
+		}
+	}
+	if ours, _ := isOurs(filepath.Join(hooksPath, "post-commit"+chainSuffix)); ours {
+		t.Fatal("original post-commit hook was overwritten by a reinstall")
+	}
+
+	cmd := exec.Command(filepath.Join(hooksPath, "post-commit"), "arg1")
+	cmd.Dir = repo
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("run hook: %v: %s", err, out)
+	}
+	if got := readEventually(t, chained); got != "arg1" {
+		t.Errorf("chained hook got %q, want %q", got, "arg1")
+	}
+	if got := readEventually(t, notified); got != "post-commit" {
+		t.Errorf("notifier got %q, want %q", got, "post-commit")
+	}
+
+	if _, err := UninstallRepo(repo); err != nil {
+		t.Fatalf("UninstallRepo: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(hooksPath, "post-commit"))
+	if err != nil || !strings.Contains(string(data), chained) {
+		t.Errorf("original post-commit hook not restored: %q, %v", data, err)
+	}
+	for _, name := range Names[1:] {
+		if _, err := os.Stat(filepath.Join(hooksPath, name)); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", name, err)
+		}
+	}
+}
+
+func TestInstallRepoRefusesHooksInWorktree(t *testing.T) {
+	isolateGit(t)
+	repo := initRepo(t)
+	husky := filepath.Join(repo, ".husky")
+	if err := os.MkdirAll(husky, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeScript(t, filepath.Join(husky, "post-checkout"), "true")
+	git(t, "-C", repo, "config", "core.hooksPath", ".husky")
+
+	notify, _ := recordingNotifier(t)
+	if _, err := InstallRepo(repo, notify, false); err == nil {
+		t.Fatal("InstallRepo into .husky succeeded without force")
+	}
+	if _, err := os.Stat(filepath.Join(husky, "post-checkout"+chainSuffix)); !os.IsNotExist(err) {
+		t.Error("refused install still renamed a tracked hook")
+	}
+
+	dir, err := InstallRepo(repo, notify, true)
+	if err != nil {
+		t.Fatalf("InstallRepo with force: %v", err)
+	}
+	if dir != husky {
+		t.Errorf("hooks directory = %q, want %q", dir, husky)
+	}
+}
+
+func TestInstallGlobalRestoresPreviousHooksPath(t *testing.T) {
+	home := isolateGit(t)
+	previous := filepath.Join(home, "old-hooks")
+	git(t, "config", "--global", "core.hooksPath", previous)
+
+	dir := filepath.Join(home, "awagent-hooks")
+	notify, _ := recordingNotifier(t)
+	if err := InstallGlobal(dir, notify); err != nil {
+		t.Fatalf("InstallGlobal: %v", err)
+	}
+	if got := git(t, "config", "--global", "core.hooksPath"); got != dir {
+		t.Errorf("core.hooksPath = %q, want %q", got, dir)
+	}
+	script, err := os.ReadFile(filepath.Join(dir, "post-merge"))
+	if err != nil || !strings.Contains(string(script), filepath.Join(previous, "post-merge")) {
+		t.Errorf("post-merge does not chain to the previous hooks path: %q, %v", script, err)
+	}
+
+	// A second install must keep the hooks path recorded by the first
+	if err := InstallGlobal(dir, notify); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if err := UninstallGlobal(dir); err != nil {
+		t.Fatalf("UninstallGlobal: %v", err)
+	}
+	if got := git(t, "config", "--global", "core.hooksPath"); got != previous {
+		t.Errorf("core.hooksPath after uninstall = %q, want %q", got, previous)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("hooks directory not emptied: %v", entries)
+	}
+}
@@ -0,0 +1,247 @@
+package privacy
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+)
+
+// RedactedValue replaces the content of redacted fields in published events.
+const RedactedValue = "[redacted]"
+
+// redactableFields lists the published event fields a rule may redact.
+var redactableFields = map[string]bool{
+	"gitUser":        true,
+	"gitEmail":       true,
+	"repoName":       true,
+	"repoId":         true,
+	"remotes":        true,
+	"upstream":       true,
+	"upstreamRepoId": true,
+	"repoPath":       true,
+	"mainRepoPath":   true,
+	"branch":         true,
+	"remote":         true,
+	"app":            true,
+	"title":          true,
+	"commits":        true,
+	"files":          true,
+	"project":        true,
+	"component":      true,
+	"pullRequest":    true,
+}
+
+// derivedFields lists the fields that reveal another field's value and are
+// redacted along with it.
+var derivedFields = map[string][]string{
+	"repoName": {"repoId", "upstreamRepoId"},
+	"remote":   {"repoId", "remotes", "upstreamRepoId"},
+	"repoPath": {"mainRepoPath"},
+	"branch":   {"upstream"},
+}
+
+// Subject describes the activity a policy is evaluated against.
+type Subject struct {
+	Path   string
+	Remote string
+	Branch string
+	App    string
+	Title  string
+}
+
+// Policy is a compiled set of exclusion and redaction rules.
+type Policy struct {
+	rules []rule
+}
+
+type rule struct {
+	paths    []*regexp.Regexp
+	remotes  []*regexp.Regexp
+	branches []*regexp.Regexp
+	apps     []string
+	titles   []*regexp.Regexp
+	exclude  bool
+	redact   []string
+}
+
+// New compiles privacy rules from configuration.
+func New(cfg config.PrivacyConfig) (*Policy, error) {
+	policy := &Policy{rules: make([]rule, 0, len(cfg.Rules))}
+
+	for i, rc := range cfg.Rules {
+		r := rule{exclude: rc.Exclude}
+
+		for _, pattern := range rc.Paths {
+			re, err := globToRegexp(filepath.ToSlash(pattern), true)
+			if err != nil {
+				return nil, fmt.Errorf("privacy rule %d: path %q: %w", i, pattern, err)
+			}
+			r.paths = append(r.paths, re)
+		}
+		for _, pattern := range rc.Remotes {
+			re, err := globToRegexp(strings.ToLower(pattern), false)
+			if err != nil {
+				return nil, fmt.Errorf("privacy rule %d: remote %q: %w", i, pattern, err)
+			}
+			r.remotes = append(r.remotes, re)
+		}
+		for _, pattern := range rc.Branches {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("privacy rule %d: branch %q: %w", i, pattern, err)
+			}
+			r.branches = append(r.branches, re)
+		}
+		for _, app := range rc.Apps {
+			r.apps = append(r.apps, strings.ToLower(strings.TrimSpace(app)))
+		}
+		for _, pattern := range rc.Titles {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("privacy rule %d: title %q: %w", i, pattern, err)
+			}
+			r.titles = append(r.titles, re)
+		}
+		for _, field := range rc.Redact {
+			if !redactableFields[field] {
+				return nil, fmt.Errorf("privacy rule %d: unknown redact field %q", i, field)
+			}
+			r.redact = append(r.redact, field)
+		}
+
+		if !r.exclude && len(r.redact) == 0 {
+			return nil, fmt.Errorf("privacy rule %d: must set exclude or redact", i)
+		}
+		if r.empty() {
+			return nil, fmt.Errorf("privacy rule %d: no match criteria", i)
+		}
+
+		policy.rules = append(policy.rules, r)
+	}
+
+	return policy, nil
+}
+
+// Excluded reports whether activity for the subject must not be tracked at all.
+func (p *Policy) Excluded(s Subject) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.rules {
+		if r.exclude && r.matches(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces every field redacted by a matching rule in the event data.
+func (p *Policy) Redact(s Subject, data map[string]any) {
+	if p == nil {
+		return
+	}
+	for _, r := range p.rules {
+		if len(r.redact) == 0 || !r.matches(s) {
+			continue
+		}
+		for _, field := range r.redact {
+			if _, ok := data[field]; !ok {
+				continue
+			}
+			if field == "commits" || field == "files" {
+				delete(data, field)
+				continue
+			}
+			data[field] = RedactedValue
+			for _, derived := range derivedFields[field] {
+				if _, ok := data[derived]; ok {
+					data[derived] = RedactedValue
+				}
+			}
+		}
+	}
+}
+
+func (r rule) empty() bool {
+	return len(r.paths) == 0 && len(r.remotes) == 0 && len(r.branches) == 0 && len(r.apps) == 0 && len(r.titles) == 0
+}
+
+// matches requires every configured criterion kind to match; within a kind any pattern may match.
+func (r rule) matches(s Subject) bool {
+	if len(r.paths) > 0 && !anyMatch(r.paths, filepath.ToSlash(s.Path)) {
+		return false
+	}
+	if len(r.remotes) > 0 && !anyMatch(r.remotes, strings.ToLower(s.Remote)) {
+		return false
+	}
+	if len(r.branches) > 0 && !anyMatch(r.branches, s.Branch) {
+		return false
+	}
+	if len(r.apps) > 0 && !containsFold(r.apps, s.App) {
+		return false
+	}
+	if len(r.titles) > 0 && !anyMatch(r.titles, s.Title) {
+		return false
+	}
+	return true
+}
+
+func anyMatch(patterns []*regexp.Regexp, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(names []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, name := range names {
+		if name == value {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a glob into an anchored regular expression. With
+// pathSemantics, "*" stops at "/" and "**" crosses directories; otherwise "*"
+// matches any run of characters. A path glob also matches everything below it.
+func globToRegexp(glob string, pathSemantics bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else if pathSemantics {
+				b.WriteString("[^/]*")
+			} else {
+				b.WriteString(".*")
+			}
+		case '?':
+			if pathSemantics {
+				b.WriteString("[^/]")
+			} else {
+				b.WriteString(".")
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if pathSemantics {
+		b.WriteString("(/.*)?")
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,202 @@
+package privacy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/liamdn8/auto-worklog-agent/internal/config"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  bool
+		value string
+		want  bool
+	}{
+		{glob: "/home/*/work", path: true, value: "/home/me/work", want: true},
+		{glob: "/home/*/work", path: true, value: "/home/me/work/client/api", want: true},
+		{glob: "/home/*/work", path: true, value: "/home/me/other/work", want: false},
+		{glob: "/home/*/work", path: true, value: "/home/me/workshop", want: false},
+		{glob: "/home/**/secret", path: true, value: "/home/me/a/b/secret", want: true},
+		{glob: "/src/repo?", path: true, value: "/src/repo1", want: true},
+		{glob: "/src/repo?", path: true, value: "/src/repo/", want: false},
+		{glob: "/src/a.b", path: true, value: "/src/axb", want: false},
+		{glob: "*github.com:acme/*", path: false, value: "git@github.com:acme/tools/api.git", want: true},
+		{glob: "*github.com:acme/*", path: false, value: "git@github.com:other/api.git", want: false},
+		{glob: "https://host/?", path: false, value: "https://host/a/b", want: false},
+	}
+
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob, tt.path)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q): %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.value); got != tt.want {
+			t.Errorf("globToRegexp(%q, %v) matches %q = %v, want %v", tt.glob, tt.path, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.PrivacyRule
+	}{
+		{name: "unknown field", rule: config.PrivacyRule{Paths: []string{"/a"}, Redact: []string{"password"}}},
+		{name: "no action", rule: config.PrivacyRule{Paths: []string{"/a"}}},
+		{name: "no criteria", rule: config.PrivacyRule{Exclude: true}},
+		{name: "bad branch regex", rule: config.PrivacyRule{Branches: []string{"("}, Exclude: true}},
+	}
+
+	for _, tt := range tests {
+		if _, err := New(config.PrivacyConfig{Rules: []config.PrivacyRule{tt.rule}}); err == nil {
+			t.Errorf("%s: New accepted %+v", tt.name, tt.rule)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	policy, err := New(config.PrivacyConfig{Rules: []config.PrivacyRule{
+		{Paths: []string{"/home/me/clients/**"}, Branches: []string{"^secret/"}, Exclude: true},
+		{Apps: []string{"Slack"}, Exclude: true},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		subject Subject
+		want    bool
+	}{
+		{subject: Subject{Path: "/home/me/clients/acme/api", Branch: "secret/x"}, want: true},
+		{subject: Subject{Path: "/home/me/clients/acme/api", Branch: "main"}, want: false},
+		{subject: Subject{Path: "/home/me/src/api", Branch: "secret/x"}, want: false},
+		{subject: Subject{Path: "/home/me/src/api", App: "slack"}, want: true},
+	}
+	for _, tt := range tests {
+		if got := policy.Excluded(tt.subject); got != tt.want {
+			t.Errorf("Excluded(%+v) = %v, want %v", tt.subject, got, tt.want)
+		}
+	}
+	if (*Policy)(nil).Excluded(Subject{Path: "/a"}) {
+		t.Error("nil policy excluded activity")
+	}
+}
+
+// publishedData has a value for every redactable field.
+func publishedData() map[string]any {
+	return map[string]any{
+		"gitUser":        "Me",
+		"gitEmail":       "me@example.com",
+		"repoName":       "api",
+		"repoId":         "github.com/acme/api",
+		"remotes":        map[string]string{"origin": "git@github.com:acme/api.git"},
+		"upstream":       "origin/feature/x",
+		"upstreamRepoId": "github.com/acme/api",
+		"repoPath":       "/home/me/clients/acme/api",
+		"mainRepoPath":   "/home/me/clients/acme/api-main",
+		"branch":         "feature/x",
+		"remote":         "git@github.com:acme/api.git",
+		"app":            "code",
+		"title":          "main.go — api",
+		"commits":        []string{"abc"},
+		"files":          map[string]int{"main.go": 3},
+		"project":        "services/billing",
+		"component":      "@acme/billing",
+		"pullRequest":    "12",
+		"eventCount":     4,
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for field := range redactableFields {
+		t.Run(field, func(t *testing.T) {
+			policy, err := New(config.PrivacyConfig{Rules: []config.PrivacyRule{
+				{Paths: []string{"/home/me/clients/**"}, Redact: []string{field}},
+			}})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			data := publishedData()
+			policy.Redact(Subject{Path: "/home/me/clients/acme/api"}, data)
+
+			hidden := map[string]bool{field: true}
+			for _, derived := range derivedFields[field] {
+				hidden[derived] = true
+			}
+			want := publishedData()
+			for name := range hidden {
+				if name == "commits" || name == "files" {
+					delete(want, name)
+				} else {
+					want[name] = RedactedValue
+				}
+			}
+			if !reflect.DeepEqual(data, want) {
+				t.Errorf("redacting %s:\n got %v\nwant %v", field, data, want)
+			}
+		})
+	}
+}
+
+func TestRedactDerivedFields(t *testing.T) {
+	tests := []struct {
+		field  string
+		hidden []string
+	}{
+		{field: "repoName", hidden: []string{"repoName", "repoId", "upstreamRepoId"}},
+		{field: "remote", hidden: []string{"remote", "repoId", "remotes", "upstreamRepoId"}},
+		{field: "repoPath", hidden: []string{"repoPath", "mainRepoPath"}},
+		{field: "branch", hidden: []string{"branch", "upstream"}},
+	}
+
+	for _, tt := range tests {
+		policy, err := New(config.PrivacyConfig{Rules: []config.PrivacyRule{
+			{Remotes: []string{"*acme/*"}, Redact: []string{tt.field}},
+		}})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		data := publishedData()
+		policy.Redact(Subject{Remote: "git@github.com:Acme/api.git"}, data)
+
+		redacted := 0
+		for _, value := range data {
+			if value == RedactedValue {
+				redacted++
+			}
+		}
+		for _, name := range tt.hidden {
+			if data[name] != RedactedValue {
+				t.Errorf("redacting %s left %s = %v", tt.field, name, data[name])
+			}
+		}
+		if redacted != len(tt.hidden) {
+			t.Errorf("redacting %s hid %d fields, want %d", tt.field, redacted, len(tt.hidden))
+		}
+	}
+}
+
+func TestRedactOnlyMatchingSubjects(t *testing.T) {
+	policy, err := New(config.PrivacyConfig{Rules: []config.PrivacyRule{
+		{Paths: []string{"/home/me/clients"}, Titles: []string{`(?i)acme`}, Redact: []string{"title", "gitEmail"}},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	data := publishedData()
+	policy.Redact(Subject{Path: "/home/me/clients/acme/api", Title: "notes.md — other"}, data)
+	if !reflect.DeepEqual(data, publishedData()) {
+		t.Errorf("rule redacted a subject whose title did not match: %v", data)
+	}
+
+	// Absent fields stay absent rather than appearing as redacted
+	data = map[string]any{"repoName": "api"}
+	policy.Redact(Subject{Path: "/home/me/clients/acme/api", Title: "ACME tracker"}, data)
+	if _, ok := data["title"]; ok {
+		t.Errorf("redaction added a title: %v", data)
+	}
+}
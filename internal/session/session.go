@@ -1,6 +1,7 @@
 package session
 
 import (
+	"slices"
 	"time"
 
 	"github.com/liamdn8/auto-worklog-agent/internal/gitinfo"
@@ -16,6 +17,14 @@ type State struct {
 	StartCommit  string           // Commit hash at session start
 	Commits      []gitinfo.Commit // All commits made during this session
 	App          string           // Application name (IDE) where activity was detected
+	Title        string           // Most recent window title attributed to the session
+	Files        map[string]int   // Focused files and how many activity events each received
+	Category     string           // Activity category of the matching app rule, e.g. "coding"
+	Project      string           // Monorepo sub-project the focused files belong to
+	Component    string           // Owning team or component from CODEOWNERS
+	Review       string           // Pull/merge request number for review sessions
+	Operation    string           // Git operation in progress, e.g. "rebase"
+	Operations   []string         // Git operations seen in progress during the session
 }
 
 // NewState constructs a fresh session state.
@@ -29,6 +38,7 @@ func NewState(repo gitinfo.Info, branch string, ts time.Time, app string) *State
 		StartCommit:  "",
 		Commits:      []gitinfo.Commit{},
 		App:          app,
+		Files:        map[string]int{},
 	}
 }
 
@@ -44,7 +54,43 @@ func (s *State) Touch(branch string, app string, ts time.Time) {
 	s.Events++
 }
 
+// RecordFile counts an activity event against the focused file.
+func (s *State) RecordFile(file string) {
+	if file == "" {
+		return
+	}
+	s.Files[file]++
+}
+
+// RecordOperation sets the git operation in progress, "" when there is none,
+// and remembers each operation the session saw.
+func (s *State) RecordOperation(operation string) {
+	s.Operation = operation
+	if operation != "" && !slices.Contains(s.Operations, operation) {
+		s.Operations = append(s.Operations, operation)
+	}
+}
+
 // Duration returns the elapsed active duration of the session.
 func (s *State) Duration() time.Duration {
 	return s.LastActivity.Sub(s.Start)
 }
+
+// SplitAt truncates the session at the given instant and returns a new session
+// continuing from that instant with the same repository, branch and app.
+func (s *State) SplitAt(at time.Time) *State {
+	s.LastActivity = at
+	next := NewState(s.Repo, s.Branch, at, s.App)
+	next.Title = s.Title
+	next.Category = s.Category
+	next.Project = s.Project
+	next.Component = s.Component
+	next.Review = s.Review
+	next.RecordOperation(s.Operation)
+	next.StartCommit = s.StartCommit
+	if len(s.Commits) > 0 {
+		next.StartCommit = s.Commits[len(s.Commits)-1].Hash
+	}
+	next.Events = 0
+	return next
+}
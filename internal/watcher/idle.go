@@ -0,0 +1,22 @@
+package watcher
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// IdleTime returns how long the user has gone without keyboard or mouse input.
+// Platform-specific implementations are in idle_linux.go, idle_darwin.go, idle_windows.go
+func IdleTime() (time.Duration, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return getIdleTimeLinux()
+	case "darwin":
+		return getIdleTimeMacOS()
+	case "windows":
+		return getIdleTimeWindows()
+	default:
+		return 0, fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+}
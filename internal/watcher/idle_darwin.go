@@ -0,0 +1,22 @@
+//go:build darwin
+// +build darwin
+
+package watcher
+
+import (
+	"fmt"
+	"time"
+)
+
+func getIdleTimeMacOS() (time.Duration, error) {
+	return 0, fmt.Errorf("idle detection not implemented on macOS")
+}
+
+// Stubs for other platforms (not compiled on macOS)
+func getIdleTimeLinux() (time.Duration, error) {
+	return 0, fmt.Errorf("Linux not supported")
+}
+
+func getIdleTimeWindows() (time.Duration, error) {
+	return 0, fmt.Errorf("Windows not supported")
+}
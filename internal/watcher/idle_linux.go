@@ -0,0 +1,155 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleSources are tried in order of precision. X11 reports exact input idle
+// time, Mutter covers GNOME on Wayland and logind is the coarse fallback
+// driven by the desktop's own idle policy.
+var idleSources = []func() (time.Duration, error){
+	tryXprintidle,        // MIT-SCREEN-SAVER extension on X11
+	tryMutterIdleMonitor, // GNOME Mutter IdleMonitor, works on Wayland
+	tryLogindIdleHint,    // systemd-logind IdleHint/IdleSinceHint
+}
+
+// idleSource is the index of the source that answered last, -1 before the
+// first probe. Polls only run that source and probe again once it fails,
+// rather than forking every missing tool on every poll.
+var (
+	idleMu     sync.Mutex
+	idleSource = -1
+)
+
+// getIdleTimeLinux returns the idle time from the source that worked last,
+// probing the sources in order when there is none or it stopped working.
+func getIdleTimeLinux() (time.Duration, error) {
+	idleMu.Lock()
+	defer idleMu.Unlock()
+
+	if idleSource >= 0 {
+		if idle, err := idleSources[idleSource](); err == nil {
+			return idle, nil
+		}
+		idleSource = -1
+	}
+
+	for i, source := range idleSources {
+		if idle, err := source(); err == nil {
+			idleSource = i
+			return idle, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no idle detection method available. Please install xprintidle or run under GNOME/systemd-logind")
+}
+
+func tryXprintidle() (time.Duration, error) {
+	// xprintidle prints milliseconds since the last input event
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	ms, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse xprintidle output: %w", err)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func tryMutterIdleMonitor() (time.Duration, error) {
+	out, err := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.gnome.Mutter.IdleMonitor",
+		"--object-path", "/org/gnome/Mutter/IdleMonitor/Core",
+		"--method", "org.gnome.Mutter.IdleMonitor.GetIdletime").Output()
+	if err != nil {
+		return 0, err
+	}
+
+	// Parse output like: (uint64 12345,)
+	ms, err := parseGVariantUint(string(out))
+	if err != nil {
+		return 0, fmt.Errorf("parse mutter idle time: %w", err)
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func tryLogindIdleHint() (time.Duration, error) {
+	hintOut, err := logindSessionProperty("IdleHint")
+	if err != nil {
+		return 0, err
+	}
+
+	// Parse output like: (<false>,)
+	if !strings.Contains(hintOut, "true") {
+		return 0, nil
+	}
+
+	sinceOut, err := logindSessionProperty("IdleSinceHint")
+	if err != nil {
+		return 0, err
+	}
+
+	// Parse output like: (<uint64 1700000000000000>,) - microseconds since the epoch
+	usec, err := parseGVariantUint(sinceOut)
+	if err != nil {
+		return 0, fmt.Errorf("parse logind idle hint: %w", err)
+	}
+	if usec == 0 {
+		return 0, nil
+	}
+
+	idle := time.Since(time.UnixMicro(int64(usec)))
+	if idle < 0 {
+		idle = 0
+	}
+	return idle, nil
+}
+
+func logindSessionProperty(name string) (string, error) {
+	out, err := exec.Command("gdbus", "call", "--system",
+		"--dest", "org.freedesktop.login1",
+		"--object-path", "/org/freedesktop/login1/session/auto",
+		"--method", "org.freedesktop.DBus.Properties.Get",
+		"org.freedesktop.login1.Session", name).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func parseGVariantUint(output string) (uint64, error) {
+	// Extract the value following the first "uint64 " or "uint32 " type annotation
+	for _, prefix := range []string{"uint64 ", "uint32 "} {
+		idx := strings.Index(output, prefix)
+		if idx < 0 {
+			continue
+		}
+		digits := output[idx+len(prefix):]
+		if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			digits = digits[:end]
+		}
+		return strconv.ParseUint(digits, 10, 64)
+	}
+	return 0, fmt.Errorf("no unsigned integer in %q", strings.TrimSpace(output))
+}
+
+// Stubs for other platforms (not compiled on Linux)
+func getIdleTimeMacOS() (time.Duration, error) {
+	return 0, fmt.Errorf("macOS not supported")
+}
+
+func getIdleTimeWindows() (time.Duration, error) {
+	return 0, fmt.Errorf("Windows not supported")
+}
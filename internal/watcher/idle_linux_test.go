@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseGVariantUint(t *testing.T) {
+	tests := []struct {
+		output string
+		want   uint64
+		ok     bool
+	}{
+		{output: "(uint64 12345,)\n", want: 12345, ok: true},
+		{output: "(<uint64 1700000000000000>,)\n", want: 1700000000000000, ok: true},
+		{output: "(uint32 7,)", want: 7, ok: true},
+		{output: "(<false>,)", ok: false},
+		{output: "(uint64 ,)", ok: false},
+		{output: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGVariantUint(tt.output)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseGVariantUint(%q) = %d, %v; want %d, ok=%v", tt.output, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGetIdleTimeLinuxRemembersSource(t *testing.T) {
+	calls := make([]int, 3)
+	failing := map[int]bool{0: true}
+	source := func(i int) func() (time.Duration, error) {
+		return func() (time.Duration, error) {
+			calls[i]++
+			if failing[i] {
+				return 0, errors.New("unavailable")
+			}
+			return time.Duration(i) * time.Second, nil
+		}
+	}
+
+	saved := idleSources
+	idleSources = []func() (time.Duration, error){source(0), source(1), source(2)}
+	idleSource = -1
+	t.Cleanup(func() {
+		idleSources = saved
+		idleSource = -1
+	})
+
+	for i := 0; i < 3; i++ {
+		if idle, err := getIdleTimeLinux(); err != nil || idle != time.Second {
+			t.Fatalf("poll %d = %s, %v; want 1s from the second source", i, idle, err)
+		}
+	}
+	if calls[0] != 1 || calls[1] != 3 || calls[2] != 0 {
+		t.Errorf("calls = %v, want the failing source probed once and the working one polled", calls)
+	}
+
+	// Once the remembered source fails the sources are probed again
+	failing[1] = true
+	if idle, err := getIdleTimeLinux(); err != nil || idle != 2*time.Second {
+		t.Fatalf("after failure = %s, %v; want 2s from the third source", idle, err)
+	}
+	if idleSource != 2 {
+		t.Errorf("idleSource = %d, want 2", idleSource)
+	}
+}
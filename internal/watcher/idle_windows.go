@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package watcher
+
+import (
+	"fmt"
+	"time"
+)
+
+func getIdleTimeWindows() (time.Duration, error) {
+	return 0, fmt.Errorf("idle detection not implemented on Windows")
+}
+
+// Stubs for other platforms (not compiled on Windows)
+func getIdleTimeLinux() (time.Duration, error) {
+	return 0, fmt.Errorf("Linux not supported")
+}
+
+func getIdleTimeMacOS() (time.Duration, error) {
+	return 0, fmt.Errorf("macOS not supported")
+}
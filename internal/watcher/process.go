@@ -0,0 +1,36 @@
+package watcher
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ForegroundCwd returns the working directory of the foreground job running
+// under the given process, e.g. the shell or editor inside a terminal window.
+// Platform-specific implementations are in process_linux.go and process_other.go
+func ForegroundCwd(pid int) (string, error) {
+	if pid <= 0 {
+		return "", fmt.Errorf("no process id for window")
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return foregroundCwdLinux(pid)
+	default:
+		return "", fmt.Errorf("foreground cwd unsupported on %s", runtime.GOOS)
+	}
+}
+
+// ProcessPaths returns filesystem paths associated with a process and its
+// descendants: command-line arguments that name existing paths, working
+// directories and open files, in that order and without duplicates.
+func ProcessPaths(pid int) ([]string, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("no process id for window")
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return processPathsLinux(pid)
+	default:
+		return nil, fmt.Errorf("process paths unsupported on %s", runtime.GOOS)
+	}
+}
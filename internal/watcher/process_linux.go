@@ -0,0 +1,235 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procStat holds the /proc/<pid>/stat fields needed to find foreground jobs.
+type procStat struct {
+	pid       int
+	comm      string
+	ppid      int
+	pgrp      int
+	ttyNr     int
+	tpgid     int
+	startTime uint64
+}
+
+// foregroundCwdLinux walks the process tree below a terminal emulator and
+// returns the cwd of the most recently started foreground job on one of its ttys.
+func foregroundCwdLinux(pid int) (string, error) {
+	procs, err := readProcTable()
+	if err != nil {
+		return "", err
+	}
+
+	children := make(map[int][]int, len(procs))
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+
+	var best *procStat
+	queue := append([]int{}, children[pid]...)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		queue = append(queue, children[current]...)
+
+		p, ok := procs[current]
+		if !ok || p.ttyNr == 0 || p.tpgid <= 0 || p.pgrp != p.tpgid {
+			continue
+		}
+		if best == nil || p.startTime > best.startTime {
+			best = p
+		}
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("no foreground job under pid %d", pid)
+	}
+
+	// A tmux client sits in the directory tmux was started from; ask the
+	// server for the pane shown by this client instead
+	if best.comm == "tmux" || strings.HasPrefix(best.comm, "tmux:") {
+		return tmuxPaneCwd(best)
+	}
+
+	cwd, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(best.pid), "cwd"))
+	if err != nil {
+		return "", fmt.Errorf("read cwd of pid %d: %w", best.pid, err)
+	}
+	return cwd, nil
+}
+
+// maxFDsPerProcess bounds how many open files are inspected per process;
+// IDEs with large indexes can hold thousands.
+const maxFDsPerProcess = 4096
+
+// processPathsLinux collects cmdline path arguments, cwds and open files of
+// pid and all of its descendants from /proc.
+func processPathsLinux(pid int) ([]string, error) {
+	procs, err := readProcTable()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int, len(procs))
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+
+	tree := []int{pid}
+	for i := 0; i < len(tree); i++ {
+		tree = append(tree, children[tree[i]]...)
+	}
+
+	seen := make(map[string]bool)
+	var args, cwds, files []string
+	add := func(list *[]string, path string) {
+		path = strings.TrimSuffix(path, " (deleted)")
+		if !filepath.IsAbs(path) || seen[path] {
+			return
+		}
+		if strings.HasPrefix(path, "/proc/") || strings.HasPrefix(path, "/dev/") || strings.HasPrefix(path, "/sys/") {
+			return
+		}
+		seen[path] = true
+		*list = append(*list, path)
+	}
+
+	for _, p := range tree {
+		base := filepath.Join("/proc", strconv.Itoa(p))
+
+		for _, arg := range processCmdline(p) {
+			if filepath.IsAbs(arg) {
+				if _, err := os.Stat(arg); err == nil {
+					add(&args, filepath.Clean(arg))
+				}
+			}
+		}
+
+		if cwd, err := os.Readlink(filepath.Join(base, "cwd")); err == nil {
+			add(&cwds, cwd)
+		}
+
+		fds, err := os.ReadDir(filepath.Join(base, "fd"))
+		if err != nil {
+			continue
+		}
+		for i, fd := range fds {
+			if i >= maxFDsPerProcess {
+				break
+			}
+			if target, err := os.Readlink(filepath.Join(base, "fd", fd.Name())); err == nil {
+				add(&files, target)
+			}
+		}
+	}
+
+	return append(append(args, cwds...), files...), nil
+}
+
+// processCmdline returns the NUL-separated command line of a process.
+func processCmdline(pid int) []string {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+}
+
+func readProcTable() (map[int]*procStat, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("read /proc: %w", err)
+	}
+
+	procs := make(map[int]*procStat, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if p, err := readProcStat(pid); err == nil {
+			procs[pid] = p
+		}
+	}
+	return procs, nil
+}
+
+func readProcStat(pid int) (*procStat, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Format: pid (comm) state ppid pgrp session tty_nr tpgid ... starttime(22nd)
+	// comm may contain spaces and parentheses, so split on the last ")"
+	line := string(data)
+	open := strings.IndexByte(line, '(')
+	end := strings.LastIndexByte(line, ')')
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("short stat for pid %d", pid)
+	}
+
+	startTime, _ := strconv.ParseUint(fields[19], 10, 64)
+	return &procStat{
+		pid:       pid,
+		comm:      line[open+1 : end],
+		ppid:      parseInt(fields[1]),
+		pgrp:      parseInt(fields[2]),
+		ttyNr:     parseInt(fields[4]),
+		tpgid:     parseInt(fields[5]),
+		startTime: startTime,
+	}, nil
+}
+
+// tmuxPaneCwd returns the current path of the pane the tmux client p shows.
+// Clients are told apart by their tty, so with several attached clients the
+// one in the focused terminal is used rather than the most recently active
+// one that display-message would report.
+func tmuxPaneCwd(p *procStat) (string, error) {
+	tty := processTTY(p)
+	if tty == "" {
+		return "", fmt.Errorf("no tty for tmux client pid %d", p.pid)
+	}
+	out, err := exec.Command("tmux", "list-clients", "-F", "#{client_tty}\t#{pane_current_path}").Output()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		clientTTY, cwd, ok := strings.Cut(line, "\t")
+		if ok && clientTTY == tty && cwd != "" {
+			return cwd, nil
+		}
+	}
+	return "", fmt.Errorf("no tmux client on %s", tty)
+}
+
+// processTTY returns the terminal device of a process: where its stdin points
+// or, failing that, the /dev/pts device its controlling tty number names.
+func processTTY(p *procStat) string {
+	if target, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(p.pid), "fd", "0")); err == nil && strings.HasPrefix(target, "/dev/") {
+		return target
+	}
+	// tty_nr packs the major number in bits 8-15; pseudo-terminals use 136-143
+	major := (p.ttyNr >> 8) & 0xfff
+	minor := (p.ttyNr & 0xff) | ((p.ttyNr >> 12) & 0xfff00)
+	if major >= 136 && major <= 143 {
+		return fmt.Sprintf("/dev/pts/%d", (major-136)<<8|minor)
+	}
+	return ""
+}
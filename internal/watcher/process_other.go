@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package watcher
+
+import (
+	"fmt"
+)
+
+// Stubs for other platforms (not compiled on Linux)
+func foregroundCwdLinux(pid int) (string, error) {
+	return "", fmt.Errorf("Linux not supported")
+}
+
+func processPathsLinux(pid int) ([]string, error) {
+	return nil, fmt.Errorf("Linux not supported")
+}
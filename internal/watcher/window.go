@@ -9,8 +9,11 @@ import (
 
 // WindowInfo represents the currently active window.
 type WindowInfo struct {
-	App   string
-	Title string
+	App     string
+	Title   string
+	PID     int      // owning process, 0 when unknown
+	Exe     string   // executable path of the owning process, empty when unknown
+	Cmdline []string // command line of the owning process, when available
 }
 
 // GetActiveWindow returns information about the currently focused window.
@@ -6,6 +6,7 @@ package watcher
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -14,11 +15,12 @@ func getActiveWindowMacOS() (WindowInfo, error) {
 	script := `tell application "System Events"
 		set frontApp to first application process whose frontmost is true
 		set appName to name of frontApp
+		set appPID to unix id of frontApp
 		try
 			set windowTitle to name of front window of frontApp
-			return appName & "|" & windowTitle
+			return appName & "|" & appPID & "|" & windowTitle
 		on error
-			return appName & "|"
+			return appName & "|" & appPID & "|"
 		end try
 	end tell`
 
@@ -27,17 +29,43 @@ func getActiveWindowMacOS() (WindowInfo, error) {
 		return WindowInfo{}, err
 	}
 
-	parts := strings.SplitN(strings.TrimSpace(string(out)), "|", 2)
-	if len(parts) >= 2 {
-		return WindowInfo{App: parts[0], Title: parts[1]}, nil
+	parts := strings.SplitN(strings.TrimSpace(string(out)), "|", 3)
+	if len(parts) == 3 {
+		pid, _ := strconv.Atoi(parts[1])
+		return WindowInfo{App: parts[0], Title: parts[2], PID: pid, Exe: processExe(pid), Cmdline: processCmdline(pid)}, nil
 	}
-	if len(parts) == 1 {
+	if len(parts) >= 1 && parts[0] != "" {
 		return WindowInfo{App: parts[0], Title: ""}, nil
 	}
 
 	return WindowInfo{}, fmt.Errorf("could not parse window info")
 }
 
+// processExe resolves the executable of a process via ps.
+func processExe(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// processCmdline reads the command line of a process via ps. ps joins the
+// arguments with spaces, so arguments containing spaces come back split.
+func processCmdline(pid int) []string {
+	if pid <= 0 {
+		return nil
+	}
+	out, err := exec.Command("ps", "-o", "args=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
 // Stubs for other platforms (not compiled on macOS)
 func getActiveWindowLinux() (WindowInfo, error) {
 	return WindowInfo{}, fmt.Errorf("Linux not supported")
@@ -5,6 +5,7 @@ package watcher
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -62,7 +63,13 @@ func tryXdotool() (WindowInfo, error) {
 		app = strings.TrimSpace(string(classOut))
 	}
 
-	return WindowInfo{App: app, Title: title}, nil
+	// Get owning process (_NET_WM_PID)
+	pid := 0
+	if pidOut, err := exec.Command("xdotool", "getwindowpid", windowIDStr).Output(); err == nil {
+		pid = parseInt(string(pidOut))
+	}
+
+	return WindowInfo{App: app, Title: title, PID: pid, Exe: processExe(pid), Cmdline: processCmdline(pid)}, nil
 }
 
 func tryXprop() (WindowInfo, error) {
@@ -92,7 +99,15 @@ func tryXprop() (WindowInfo, error) {
 		app = extractXpropClass(string(classOut))
 	}
 
-	return WindowInfo{App: app, Title: title}, nil
+	// Get owning process from: _NET_WM_PID(CARDINAL) = 1234
+	pid := 0
+	if pidOut, err := exec.Command("xprop", "-id", windowID, "_NET_WM_PID").Output(); err == nil {
+		if idx := strings.Index(string(pidOut), "= "); idx >= 0 {
+			pid = parseInt(string(pidOut)[idx+2:])
+		}
+	}
+
+	return WindowInfo{App: app, Title: title, PID: pid, Exe: processExe(pid), Cmdline: processCmdline(pid)}, nil
 }
 
 func tryGdbus() (WindowInfo, error) {
@@ -228,7 +243,21 @@ func extractXpropClass(output string) string {
 func parseInt(s string) int {
 	i, _ := strconv.Atoi(strings.TrimSpace(s))
 	return i
-} // Stubs for other platforms (not compiled on Linux)
+}
+
+// processExe resolves the executable of a process via /proc.
+func processExe(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(exe, " (deleted)")
+}
+
+// Stubs for other platforms (not compiled on Linux)
 func getActiveWindowMacOS() (WindowInfo, error) {
 	return WindowInfo{}, fmt.Errorf("macOS not supported")
 }
@@ -6,6 +6,7 @@ package watcher
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -31,24 +32,57 @@ func getActiveWindowWindows() (WindowInfo, error) {
 	[void][Win32]::GetWindowThreadProcessId($hwnd, [ref]$processId)
 	$process = Get-Process -Id $processId -ErrorAction SilentlyContinue
 	if ($process) {
-		Write-Output "$($process.ProcessName)|$($title.ToString())"
+		Write-Output "$($process.ProcessName)|$processId|$($process.Path)|$($title.ToString())"
 	} else {
-		Write-Output "unknown|$($title.ToString())"
-	}`
+		Write-Output "unknown|$processId||$($title.ToString())"
+	}
+	$cim = Get-CimInstance Win32_Process -Filter "ProcessId = $processId" -ErrorAction SilentlyContinue
+	if ($cim) { Write-Output $cim.CommandLine }`
 
 	out, err := exec.Command("powershell", "-Command", script).Output()
 	if err != nil {
 		return WindowInfo{}, err
 	}
 
-	parts := strings.SplitN(strings.TrimSpace(string(out)), "|", 2)
-	if len(parts) >= 2 {
-		return WindowInfo{App: parts[0], Title: parts[1]}, nil
+	// The command line, when readable, follows on a second line
+	window, cmdline, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	parts := strings.SplitN(strings.TrimSpace(window), "|", 4)
+	if len(parts) == 4 {
+		pid, _ := strconv.Atoi(parts[1])
+		return WindowInfo{App: parts[0], Title: parts[3], PID: pid, Exe: parts[2], Cmdline: splitCommandLine(cmdline)}, nil
 	}
 
 	return WindowInfo{}, fmt.Errorf("could not parse window info")
 }
 
+// splitCommandLine splits a Windows command line into arguments. Double
+// quotes group arguments containing spaces; backslashes are kept as they
+// are, which is all paths need.
+func splitCommandLine(cmdline string) []string {
+	var args []string
+	var current strings.Builder
+	quoted, started := false, false
+	for _, r := range strings.TrimSpace(cmdline) {
+		switch {
+		case r == '"':
+			quoted, started = !quoted, true
+		case (r == ' ' || r == '\t') && !quoted:
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		args = append(args, current.String())
+	}
+	return args
+}
+
 // Stubs for other platforms (not compiled on Windows)
 func getActiveWindowLinux() (WindowInfo, error) {
 	return WindowInfo{}, fmt.Errorf("Linux not supported")